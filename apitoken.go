@@ -0,0 +1,66 @@
+package nflpickem
+
+import "time"
+
+// TokenScope limits what a request authenticated with an API token may do.
+type TokenScope string
+
+const (
+	// ScopeReadOnly allows a token to read, but never change, anything its user can see
+	ScopeReadOnly TokenScope = "read-only"
+	// ScopePicksWrite additionally allows a token to make picks on behalf of its user
+	ScopePicksWrite TokenScope = "picks:write"
+)
+
+// Allows returns whether or not a token with this scope can do everything allowed by other.
+func (s TokenScope) Allows(other TokenScope) bool {
+	switch s {
+	case ScopePicksWrite:
+		return other == ScopePicksWrite || other == ScopeReadOnly
+	case ScopeReadOnly:
+		return other == ScopeReadOnly
+	default:
+		return false
+	}
+}
+
+// ParseTokenScope validates the name of a TokenScope.
+func ParseTokenScope(scope string) (TokenScope, error) {
+	switch s := TokenScope(scope); s {
+	case ScopeReadOnly, ScopePicksWrite:
+		return s, nil
+	default:
+		return "", ErrUnknownTokenScope
+	}
+}
+
+// APIToken is a long-lived credential a user creates for scripts and bots, so that they
+// don't need to send the user's password.
+//
+// LastUsed is the zero time if the token has never been used.
+type APIToken struct {
+	ID       int64      `json:"id"`
+	User     User       `json:"-"`
+	Name     string     `json:"name"`
+	Scope    TokenScope `json:"scope"`
+	Created  time.Time  `json:"created"`
+	LastUsed time.Time  `json:"lastUsed"`
+}
+
+var (
+	ErrInvalidAPIToken   = newError(KindBadCredentials, "invalid_api_token", "invalid API token")
+	ErrUnknownAPIToken   = newError(KindNotFound, "unknown_api_token", "unknown API token")
+	ErrUnknownTokenScope = newError(KindInvalid, "unknown_token_scope", "unknown token scope")
+)
+
+// APITokenManager is the interface implemented by types that can store API tokens.
+//
+// Only a hash of each token is stored, so the token itself is only available when it
+// is created.
+type APITokenManager interface {
+	CreateAPIToken(username string, name string, scope TokenScope, t time.Time) (token string, err error)
+	APIToken(token string) (APIToken, error)
+	APITokens(username string) ([]APIToken, error)
+	TouchAPIToken(id int64, t time.Time) error
+	RevokeAPIToken(username string, id int64) error
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 
+	"github.com/ameske/nfl-pickem"
 	"github.com/ameske/nfl-pickem/sqlite3"
 	"github.com/spf13/cobra"
 )
@@ -44,9 +45,9 @@ var createPicksCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		for i := 1; i <= 17; i++ {
+		for i := 1; i <= nflpickem.SeasonLength; i++ {
 			for _, u := range users {
-				err := db.CreatePicks(u, int(createYear), i)
+				err := db.CreatePicks(u.Email, int(createYear), i)
 				if err != nil {
 					log.Fatal(err)
 				}
@@ -15,6 +15,8 @@ func main() {
 	rootCmd.AddCommand(ScheduleCmd)
 	rootCmd.AddCommand(TestCmd)
 	rootCmd.AddCommand(CreateCmd)
+	rootCmd.AddCommand(StandingsCmd)
+	rootCmd.AddCommand(UserCmd)
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().StringVarP(&datastore, "db", "d", "", "path to datastore")
 	rootCmd.Execute()
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	nflpickem "github.com/ameske/nfl-pickem"
+	"github.com/ameske/nfl-pickem/sqlite3"
+	"github.com/spf13/cobra"
+)
+
+var standingsYear, standingsWeek uint
+var simulateModel string
+var simulateTrials int
+var simulateSeed int64
+
+func init() {
+	StandingsCmd.AddCommand(standingsSimulateCmd)
+
+	standingsSimulateCmd.Flags().UintVarP(&standingsYear, "year", "y", 0, "NFL season year, defaults to the current week")
+	standingsSimulateCmd.Flags().UintVarP(&standingsWeek, "week", "w", 0, "NFL season week, defaults to the current week")
+	standingsSimulateCmd.Flags().StringVarP(&simulateModel, "model", "m", string(nflpickem.CoinFlip), "odds model for unplayed games [coin, record]")
+	standingsSimulateCmd.Flags().IntVarP(&simulateTrials, "trials", "t", 10000, "number of simulated weeks")
+	standingsSimulateCmd.Flags().Int64VarP(&simulateSeed, "seed", "s", 0, "seed for the simulation, defaults to the current time")
+}
+
+var StandingsCmd = &cobra.Command{
+	Use:   "standings",
+	Short: "query the pool standings",
+	Long:  "query the pool standings",
+}
+
+var standingsSimulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "simulate each user's chance of winning the week",
+	Long:  "simulate the remaining games of a week and report each user's chance of winning it",
+	Run: func(cmd *cobra.Command, args []string) {
+		if datastore == "" {
+			log.Fatal("db flag is required")
+		}
+
+		db, err := sqlite3.NewDatastore(datastore)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		year, week := int(standingsYear), int(standingsWeek)
+		if year == 0 || week == 0 {
+			current, err := db.CurrentWeek(time.Now())
+			if err != nil {
+				log.Fatal(err)
+			}
+			year, week = current.Year, current.Week
+		}
+
+		if !cmd.Flags().Changed("seed") {
+			simulateSeed = time.Now().UnixNano()
+		}
+
+		probabilities, err := db.SimulateWeek(time.Now(), year, week, nflpickem.OddsModel(simulateModel), simulateTrials, simulateSeed)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if verbose {
+			log.Printf("simulated %d-%d with model %s, %d trials, seed %d", year, week, simulateModel, simulateTrials, simulateSeed)
+		}
+
+		err = json.NewEncoder(os.Stdout).Encode(&probabilities)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ameske/nfl-pickem/sqlite3"
+	"github.com/spf13/cobra"
+)
+
+var userFirst, userLast, userEmail, userPassword string
+var userAdmin, userUndo bool
+
+func init() {
+	UserCmd.AddCommand(userAddCmd)
+	UserCmd.AddCommand(userListCmd)
+	UserCmd.AddCommand(userDisableCmd)
+	UserCmd.AddCommand(userPromoteCmd)
+	UserCmd.AddCommand(userResetPasswordCmd)
+	UserCmd.AddCommand(userReset2FACmd)
+
+	userAddCmd.Flags().StringVarP(&userFirst, "first", "f", "", "first name")
+	userAddCmd.Flags().StringVarP(&userLast, "last", "l", "", "last name")
+	userAddCmd.Flags().StringVarP(&userEmail, "email", "e", "", "e-mail address, used as the username")
+	userAddCmd.Flags().StringVarP(&userPassword, "password", "p", "", "initial password")
+	userAddCmd.Flags().BoolVarP(&userAdmin, "admin", "a", false, "grant administrator privileges")
+
+	userDisableCmd.Flags().StringVarP(&userEmail, "email", "e", "", "e-mail address of the user")
+	userDisableCmd.Flags().BoolVarP(&userUndo, "enable", "n", false, "re-enable the user instead")
+
+	userPromoteCmd.Flags().StringVarP(&userEmail, "email", "e", "", "e-mail address of the user")
+	userPromoteCmd.Flags().BoolVarP(&userUndo, "demote", "r", false, "revoke administrator privileges instead")
+
+	userResetPasswordCmd.Flags().StringVarP(&userEmail, "email", "e", "", "e-mail address of the user")
+	userResetPasswordCmd.Flags().StringVarP(&userPassword, "password", "p", "", "new password")
+
+	userReset2FACmd.Flags().StringVarP(&userEmail, "email", "e", "", "e-mail address of the user")
+}
+
+var UserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "manage the users of the pool",
+	Long:  "manage the users of the pool",
+}
+
+var userAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "add a user",
+	Long:  "add a user",
+	Run: func(cmd *cobra.Command, args []string) {
+		if userFirst == "" || userLast == "" || userEmail == "" || userPassword == "" {
+			log.Fatal("first, last, email, and password must be set via command line")
+		}
+
+		err := openUserDatastore().AddUser(userFirst, userLast, userEmail, userPassword, userAdmin)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var userListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list all users",
+	Long:  "list all users",
+	Run: func(cmd *cobra.Command, args []string) {
+		users, err := openUserDatastore().Users()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "EMAIL\tNAME\tADMIN\tDISABLED")
+		for _, u := range users {
+			fmt.Fprintf(tw, "%s\t%s %s\t%t\t%t\n", u.Email, u.FirstName, u.LastName, u.Admin, u.Disabled)
+		}
+		tw.Flush()
+	},
+}
+
+var userDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "disable a user",
+	Long:  "disable a user, preventing them from logging in",
+	Run: func(cmd *cobra.Command, args []string) {
+		if userEmail == "" {
+			log.Fatal("email must be set via command line")
+		}
+
+		err := openUserDatastore().DisableUser(userEmail, !userUndo)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var userPromoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "grant a user administrator privileges",
+	Long:  "grant a user administrator privileges",
+	Run: func(cmd *cobra.Command, args []string) {
+		if userEmail == "" {
+			log.Fatal("email must be set via command line")
+		}
+
+		err := openUserDatastore().PromoteUser(userEmail, !userUndo)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var userResetPasswordCmd = &cobra.Command{
+	Use:   "reset-password",
+	Short: "set a new password for a user",
+	Long:  "set a new password for a user",
+	Run: func(cmd *cobra.Command, args []string) {
+		if userEmail == "" || userPassword == "" {
+			log.Fatal("email and password must be set via command line")
+		}
+
+		err := openUserDatastore().ResetPassword(userEmail, userPassword)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var userReset2FACmd = &cobra.Command{
+	Use:   "reset-2fa",
+	Short: "remove two-factor authentication from a user",
+	Long:  "remove two-factor authentication from a user who has lost their device and recovery codes",
+	Run: func(cmd *cobra.Command, args []string) {
+		if userEmail == "" {
+			log.Fatal("email must be set via command line")
+		}
+
+		err := openUserDatastore().DisableTOTP(userEmail)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// openUserDatastore opens the datastore given on the command line, exiting on failure.
+func openUserDatastore() *sqlite3.Datastore {
+	if datastore == "" {
+		log.Fatal("db flag is required")
+	}
+
+	db, err := sqlite3.NewDatastore(datastore)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return db
+}
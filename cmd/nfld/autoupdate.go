@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/ameske/nfl-pickem"
@@ -11,74 +13,60 @@ import (
 )
 
 // scheduleUpdates sets up goroutines that will import the results of games and update the
-// picks after every wave of games completes.
-func scheduleUpdates(db nflpickem.Updater) {
+// picks after every wave of games completes, until stop is closed. The outcome of every
+// import is passed to record. The returned WaitGroup is done once every goroutine has
+// returned, after finishing any update in progress.
+func scheduleUpdates(db nflpickem.Updater, record func(error), stop <-chan struct{}) *sync.WaitGroup {
+	var wg sync.WaitGroup
+
+	weekly := func(day time.Weekday, hour int, updatePreviousWeek bool) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			next := adjustIfPast(nextDay(day).Add(time.Hour * time.Duration(hour)))
+			for {
+				logNextScheduleUpdate(next)
+
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-stop:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+
+				record(update(db, updatePreviousWeek))
+				next = next.AddDate(0, 0, 7)
+			}
+		}()
+	}
+
 	// Friday at 8:00
-	go func() {
-		nextFriday := adjustIfPast(nextDay(time.Friday).Add(time.Hour * 8))
-		logNextScheduleUpdate(nextFriday)
-		time.Sleep(nextFriday.Sub(time.Now()))
-		for {
-			go update(db, false)
-			logNextScheduleUpdate(time.Now().AddDate(0, 0, 7))
-			time.Sleep(time.Hour * 24 * 7)
-		}
-	}()
+	weekly(time.Friday, 8, false)
 
 	// Sunday at 18:00
-	go func() {
-		nextSunday := adjustIfPast(nextDay(time.Sunday).Add(time.Hour * 18))
-		logNextScheduleUpdate(nextSunday)
-		time.Sleep(nextSunday.Sub(time.Now()))
-		for {
-			go update(db, false)
-			logNextScheduleUpdate(time.Now().AddDate(0, 0, 7))
-			time.Sleep(time.Hour * 24 * 7)
-		}
-	}()
+	weekly(time.Sunday, 18, false)
 
 	// Sunday at 21:00
-	go func() {
-		nextSunday := adjustIfPast(nextDay(time.Sunday).Add(time.Hour * 21))
-		logNextScheduleUpdate(nextSunday)
-		time.Sleep(nextSunday.Sub(time.Now()))
-		for {
-			go update(db, false)
-			logNextScheduleUpdate(time.Now().AddDate(0, 0, 7))
-			time.Sleep(time.Hour * 24 * 7)
-		}
-	}()
+	weekly(time.Sunday, 21, false)
 
 	// Monday at 8:00
-	go func() {
-		nextMonday := adjustIfPast(nextDay(time.Monday).Add(time.Hour * 8))
-		logNextScheduleUpdate(nextMonday)
-		time.Sleep(nextMonday.Sub(time.Now()))
-		for {
-			go update(db, false)
-			logNextScheduleUpdate(time.Now().AddDate(0, 0, 7))
-			time.Sleep(time.Hour * 24 * 7)
-		}
-	}()
+	weekly(time.Monday, 8, false)
 
 	// Tuesday at 8:00. Here we need to update the current week - 1
-	go func() {
-		nextTuesday := adjustIfPast(nextDay(time.Tuesday).Add(time.Hour * 8))
-		logNextScheduleUpdate(nextTuesday)
-		time.Sleep(nextTuesday.Sub(time.Now()))
-		for {
-			go update(db, true)
-			logNextScheduleUpdate(time.Now().AddDate(0, 0, 7))
-			time.Sleep(time.Hour * 24 * 7)
-		}
-	}()
+	weekly(time.Tuesday, 8, true)
+
+	return &wg
 }
 
-func update(db nflpickem.Updater, updatePreviousWeek bool) {
+// update imports the results of the current week's games, or the previous week's. Games
+// that can't be updated are skipped, and reported in the returned error.
+func update(db nflpickem.Updater, updatePreviousWeek bool) error {
 	nflWeek, err := db.CurrentWeek(time.Now())
 	if err != nil {
 		log.Println(err)
-		return
+		return err
 	}
 
 	if updatePreviousWeek {
@@ -88,17 +76,25 @@ func update(db nflpickem.Updater, updatePreviousWeek bool) {
 	results, err := getGameResults(nflWeek.Year, nflWeek.Week)
 	if err != nil {
 		log.Println(err)
-		return
+		return err
 	}
 
+	failed := 0
 	for _, result := range results {
-		log.Printf("Updating Game: %v", result)
+		slog.Info("Updating game", "result", result)
 		err := db.UpdateGame(nflWeek.Week, nflWeek.Year, result.Home, result.HomeScore, result.AwayScore)
 		if err != nil {
 			log.Println(err)
+			failed++
 			continue
 		}
 	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to update %d of %d games", failed, len(results))
+	}
+
+	return nil
 }
 
 func getGameResults(year, week int) ([]results.Result, error) {
@@ -141,5 +137,5 @@ func nextDay(day time.Weekday) time.Time {
 }
 
 func logNextScheduleUpdate(t time.Time) {
-	log.Printf("Scheduling update for %v", t.Format(time.RFC1123))
+	slog.Info("Scheduling update", "at", t.Format(time.RFC1123))
 }
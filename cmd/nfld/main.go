@@ -1,30 +1,76 @@
 package main
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"log"
+	"log/slog"
 	"log/syslog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	nflpickem "github.com/ameske/nfl-pickem"
 	"github.com/ameske/nfl-pickem/http"
+	"github.com/ameske/nfl-pickem/logos"
 	"github.com/ameske/nfl-pickem/sqlite3"
+	"github.com/ameske/nfl-pickem/www"
 )
 
 // For now, we will let all of these things be global since it's easier
 
+// defaultListenAddress is the address nfld listens on unless configured otherwise
+const defaultListenAddress = "0.0.0.0:61389"
+
 type config struct {
 	Server struct {
-		AuthKey    string `json:"authKey"`
-		EncryptKey string `json:"encryptKey"`
-		Database   string `json:"databaseFile"`
-		Autoupdate bool   `json:"autoupdateEnabled"`
+		ListenAddress string `json:"listenAddress"`
+		AuthKey       string `json:"authKey"`
+		EncryptKey    string `json:"encryptKey"`
+		Database      string `json:"databaseFile"`
+		Autoupdate    bool   `json:"autoupdateEnabled"`
+		BaseURL       string `json:"baseURL"`
+		TrustProxy    bool   `json:"trustProxy"`
+		Approval      bool   `json:"requireApproval"`
+		Session       struct {
+			IdleTimeout     string `json:"idleTimeout"`
+			AbsoluteTimeout string `json:"absoluteTimeout"`
+			SecureCookie    bool   `json:"secureCookie"`
+			SameSite        string `json:"sameSite"`
+		} `json:"session"`
+		OIDC struct {
+			Issuer       string `json:"issuer"`
+			ClientID     string `json:"clientID"`
+			ClientSecret string `json:"clientSecret"`
+		} `json:"oidc"`
+		TLS struct {
+			CertFile   string `json:"certFile"`
+			KeyFile    string `json:"keyFile"`
+			SelfSigned bool   `json:"selfSigned"`
+		} `json:"tls"`
+		Timeouts struct {
+			ReadHeader string `json:"readHeader"`
+			Read       string `json:"read"`
+			Write      string `json:"write"`
+			Idle       string `json:"idle"`
+			Shutdown   string `json:"shutdown"`
+		} `json:"timeouts"`
 	} `json:"server"`
+	Log struct {
+		Level  string `json:"level"`
+		Format string `json:"format"`
+	} `json:"log"`
 	Email struct {
 		Enabled     bool   `json:"enabled"`
 		Type        string `json:"type"`
@@ -61,6 +107,142 @@ func parseSecureCookieKeys(b64HashKey, b64EncryptKey string) (hashKey []byte, en
 	return
 }
 
+// parseOptions overrides the default server options with any set in the config.
+// Timeouts use time.ParseDuration format, such as "168h".
+func parseOptions(c config) (opts http.Options, err error) {
+	opts = http.DefaultOptions
+	opts.TrustProxy = c.Server.TrustProxy
+	opts.RequireApproval = c.Server.Approval
+	opts.TLS = http.TLSOptions{
+		CertFile:   c.Server.TLS.CertFile,
+		KeyFile:    c.Server.TLS.KeyFile,
+		SelfSigned: c.Server.TLS.SelfSigned,
+	}
+
+	// The web client is built into nfld, so that it can be served without a separate web server
+	opts.Assets = map[string]fs.FS{
+		"/":       www.FS,
+		"/logos/": logos.FS,
+	}
+
+	if c.Server.OIDC.Issuer != "" {
+		if c.Server.BaseURL == "" {
+			return opts, errors.New("baseURL is required for OIDC login")
+		}
+
+		opts.OIDC = http.OIDCOptions{
+			Issuer:       c.Server.OIDC.Issuer,
+			ClientID:     c.Server.OIDC.ClientID,
+			ClientSecret: c.Server.OIDC.ClientSecret,
+			RedirectURL:  strings.TrimSuffix(c.Server.BaseURL, "/") + "/api/oidc/callback",
+		}
+	}
+
+	opts.Session, err = parseSessionOptions(c)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Timeouts, err = parseTimeoutOptions(c)
+	if err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// parseTimeoutOptions overrides the default server timeouts with any set in the config.
+func parseTimeoutOptions(c config) (to http.TimeoutOptions, err error) {
+	to = http.DefaultTimeoutOptions
+
+	timeouts := []struct {
+		value string
+		d     *time.Duration
+	}{
+		{c.Server.Timeouts.ReadHeader, &to.ReadHeader},
+		{c.Server.Timeouts.Read, &to.Read},
+		{c.Server.Timeouts.Write, &to.Write},
+		{c.Server.Timeouts.Idle, &to.Idle},
+		{c.Server.Timeouts.Shutdown, &to.Shutdown},
+	}
+
+	for _, t := range timeouts {
+		if t.value == "" {
+			continue
+		}
+
+		*t.d, err = time.ParseDuration(t.value)
+		if err != nil {
+			return to, err
+		}
+	}
+
+	return to, nil
+}
+
+// parseSessionOptions overrides the default session options with any set in the config.
+func parseSessionOptions(c config) (so http.SessionOptions, err error) {
+	so = http.DefaultSessionOptions
+	so.SecureCookie = c.Server.Session.SecureCookie
+
+	if c.Server.Session.SameSite != "" {
+		so.SameSite, err = http.ParseSameSite(c.Server.Session.SameSite)
+		if err != nil {
+			return so, err
+		}
+	}
+
+	if c.Server.Session.IdleTimeout != "" {
+		so.IdleTimeout, err = time.ParseDuration(c.Server.Session.IdleTimeout)
+		if err != nil {
+			return so, err
+		}
+	}
+
+	if c.Server.Session.AbsoluteTimeout != "" {
+		so.AbsoluteTimeout, err = time.ParseDuration(c.Server.Session.AbsoluteTimeout)
+		if err != nil {
+			return so, err
+		}
+	}
+
+	return so, nil
+}
+
+// newLogger creates the logger configured by the config, which writes "text" or "json"
+// records to out at the configured level or above. It logs informational records as text
+// unless configured otherwise.
+func newLogger(c config, out io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if c.Log.Level != "" {
+		err := level.UnmarshalText([]byte(c.Log.Level))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:     level,
+		AddSource: true,
+		// Sources are reported as file:line, like the log package's Lshortfile
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if src, ok := a.Value.Any().(*slog.Source); ok && a.Key == slog.SourceKey {
+				return slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", filepath.Base(src.File), src.Line))
+			}
+			return a
+		},
+	}
+
+	switch c.Log.Format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(out, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(out, opts)), nil
+	default:
+		return nil, fmt.Errorf("unrecognized log format: %s", c.Log.Format)
+	}
+}
+
 func setupNotifier(c config) (n nflpickem.Notifier, err error) {
 	if !c.Email.Enabled {
 		return nullNotifier{}, nil
@@ -68,9 +250,9 @@ func setupNotifier(c config) (n nflpickem.Notifier, err error) {
 
 	switch c.Email.Type {
 	case "fs":
-		n, err = fsNotifier{}, nil
+		n, err = fsNotifier{baseURL: c.Server.BaseURL}, nil
 	case "email":
-		n, err = NewEmailNotifier(c.Email.SMTPAddress, c.Email.Sender, c.Email.Password)
+		n, err = NewEmailNotifier(c.Email.SMTPAddress, c.Email.Sender, c.Email.Password, c.Server.BaseURL)
 	default:
 		n, err = nil, fmt.Errorf("unrecognized e-mail type: %s", c.Email.Type)
 	}
@@ -95,16 +277,27 @@ func main() {
 	c = loadConfig(configFile)
 
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	if stdout {
-		log.SetOutput(os.Stdout)
-	} else {
-		slog, err := syslog.New(syslog.LOG_INFO|syslog.LOG_LOCAL0, "nfl-pickem")
+
+	var out io.Writer = os.Stdout
+	if !stdout {
+		out, err = syslog.New(syslog.LOG_INFO|syslog.LOG_LOCAL0, "nfl-pickem")
 		if err != nil {
 			log.Fatal("Could not connect to syslog:", err)
 		}
-		log.SetOutput(slog)
 	}
 
+	logger, err := newLogger(c, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Everything logged with the log package goes through the same logger as requests.
+	// What is still logged that way reports errors, so it is logged at the error level,
+	// where it can't be filtered out by the configured level.
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	log.SetOutput(slog.NewLogLogger(logger.Handler(), slog.LevelError).Writer())
+
 	if dbFile != "" {
 		c.Server.Database = dbFile
 	}
@@ -118,10 +311,7 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	if c.Server.Autoupdate {
-		scheduleUpdates(db)
-	}
+	notifier = preferenceNotifier{Notifier: notifier, profiles: db}
 
 	hashKey, encryptKey, err := parseSecureCookieKeys(c.Server.AuthKey, c.Server.EncryptKey)
 	if err != nil {
@@ -140,13 +330,77 @@ func main() {
 		timeSource = http.DefaultTimesource
 	}
 
+	opts, err := parseOptions(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts.Logger = logger
+
+	address := c.Server.ListenAddress
+	if address == "" {
+		address = defaultListenAddress
+	}
+
 	prefix := "/api"
-	server, err := http.NewServer("0.0.0.0:61389", prefix, hashKey, encryptKey, db, notifier, timeSource)
+	server, err := http.NewServer(address, prefix, hashKey, encryptKey, db, notifier, timeSource, opts)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	log.Fatal(server.Start())
+	// Updates go through the server, so that they are pushed to live clients
+	stopUpdates := make(chan struct{})
+	updates := &sync.WaitGroup{}
+	if c.Server.Autoupdate {
+		updates = scheduleUpdates(server.Updater(), server.RecordUpdate, stopUpdates)
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Start()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	select {
+	case err := <-serverErr:
+		log.Fatal(err)
+	case sig := <-signals:
+		slog.Info("Shutting down", "signal", sig.String())
+	}
+
+	shutdown(server, stopUpdates, updates, opts.Timeouts.Shutdown)
+}
+
+// shutdown stops the scheduled updates and drains the server, waiting for requests in
+// progress, updates in progress and pending notifications until the timeout runs out.
+func shutdown(server *http.Server, stopUpdates chan struct{}, updates *sync.WaitGroup, timeout time.Duration) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	close(stopUpdates)
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		log.Printf("Unable to shut down cleanly: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		updates.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("NFL Pick-Em Pool stopped")
+	case <-ctx.Done():
+		slog.Warn("Gave up waiting on an update in progress")
+	}
 }
 
 // customTime implements the TimeSource interface provided by package HTTP
@@ -7,6 +7,7 @@ import (
 	"net/smtp"
 	"os"
 	"text/template"
+	"time"
 
 	"github.com/ameske/nfl-pickem"
 )
@@ -15,7 +16,36 @@ type nullNotifier struct{}
 
 func (n nullNotifier) Notify(to string, week int, picks []nflpickem.Pick) error { return nil }
 
-type fsNotifier struct{}
+func (n nullNotifier) NotifyPasswordReset(to string, token string, expires time.Time) error {
+	return nil
+}
+
+func (n nullNotifier) NotifyEmailVerification(to string, token string, expires time.Time) error {
+	return nil
+}
+
+// preferenceNotifier only sends the notifications that each user has asked for.
+type preferenceNotifier struct {
+	nflpickem.Notifier
+	profiles nflpickem.ProfileManager
+}
+
+func (n preferenceNotifier) Notify(to string, week int, picks []nflpickem.Pick) error {
+	p, err := n.profiles.Profile(to, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !p.Notifies(nflpickem.PicksEvent) {
+		return nil
+	}
+
+	return n.Notifier.Notify(to, week, picks)
+}
+
+type fsNotifier struct {
+	baseURL string
+}
 
 func (n fsNotifier) Notify(to string, week int, picks []nflpickem.Pick) error {
 	fd, err := os.Create(fmt.Sprintf("%s-%d.txt", to, week))
@@ -41,15 +71,48 @@ func (n fsNotifier) Notify(to string, week int, picks []nflpickem.Pick) error {
 	return et.Execute(fd, pe)
 }
 
+func (n fsNotifier) NotifyPasswordReset(to string, token string, expires time.Time) error {
+	fd, err := os.Create(fmt.Sprintf("%s-reset.txt", to))
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	rt, err := template.New("reset").Parse(resetBody)
+	if err != nil {
+		return err
+	}
+
+	return rt.Execute(fd, newResetEmail(to, "debugserver", n.baseURL, token, expires))
+}
+
+func (n fsNotifier) NotifyEmailVerification(to string, token string, expires time.Time) error {
+	fd, err := os.Create(fmt.Sprintf("%s-verify.txt", to))
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	vt, err := template.New("verify").Parse(verifyBody)
+	if err != nil {
+		return err
+	}
+
+	return vt.Execute(fd, newVerifyEmail(to, "debugserver", n.baseURL, token, expires))
+}
+
 type emailNotifier struct {
 	auth           smtp.Auth
 	sender         string
 	smtpServer     string
 	smtpServerPort string
+	baseURL        string
 	et             *template.Template
+	rt             *template.Template
+	vt             *template.Template
 }
 
-func NewEmailNotifier(server, sendAsAddress, password string) (nflpickem.Notifier, error) {
+func NewEmailNotifier(server, sendAsAddress, password, baseURL string) (nflpickem.Notifier, error) {
 	addr, port, err := net.SplitHostPort(server)
 	if err != nil {
 		return nil, err
@@ -60,13 +123,23 @@ func NewEmailNotifier(server, sendAsAddress, password string) (nflpickem.Notifie
 		return nil, err
 	}
 
+	rt, err := template.New("reset").Parse(resetBody)
+	if err != nil {
+		return nil, err
+	}
+
+	vt, err := template.New("verify").Parse(verifyBody)
+	if err != nil {
+		return nil, err
+	}
+
 	a := smtp.PlainAuth("",
 		sendAsAddress,
 		password,
 		addr,
 	)
 
-	return emailNotifier{auth: a, sender: sendAsAddress, smtpServer: addr, smtpServerPort: port, et: et}, nil
+	return emailNotifier{auth: a, sender: sendAsAddress, smtpServer: addr, smtpServerPort: port, baseURL: baseURL, et: et, rt: rt, vt: vt}, nil
 }
 
 func (e emailNotifier) Notify(to string, week int, picks []nflpickem.Pick) error {
@@ -80,8 +153,21 @@ func (e emailNotifier) Notify(to string, week int, picks []nflpickem.Pick) error
 		to, e.sender, fmt.Sprintf("Week %d Picks", week), week, picks,
 	}
 
+	return e.send(to, e.et, pe)
+}
+
+func (e emailNotifier) NotifyPasswordReset(to string, token string, expires time.Time) error {
+	return e.send(to, e.rt, newResetEmail(to, e.sender, e.baseURL, token, expires))
+}
+
+func (e emailNotifier) NotifyEmailVerification(to string, token string, expires time.Time) error {
+	return e.send(to, e.vt, newVerifyEmail(to, e.sender, e.baseURL, token, expires))
+}
+
+// send renders the template with data and mails the result to the given address.
+func (e emailNotifier) send(to string, t *template.Template, data interface{}) error {
 	var body bytes.Buffer
-	err := e.et.Execute(&body, pe)
+	err := t.Execute(&body, data)
 	if err != nil {
 		return err
 	}
@@ -91,6 +177,37 @@ func (e emailNotifier) Notify(to string, week int, picks []nflpickem.Pick) error
 	return smtp.SendMail(fullAddr, e.auth, e.sender, []string{to}, body.Bytes())
 }
 
+// resetEmail is the data rendered into resetBody
+type resetEmail struct {
+	To      string
+	From    string
+	Subject string
+	Link    string
+	Expires string
+}
+
+func newResetEmail(to, from, baseURL, token string, expires time.Time) resetEmail {
+	return resetEmail{
+		To:      to,
+		From:    from,
+		Subject: "NFL Pick-Em Password Reset",
+		Link:    fmt.Sprintf("%s/reset.html?token=%s", baseURL, token),
+		Expires: expires.Format(time.RFC1123),
+	}
+}
+
+// newVerifyEmail builds the data rendered into verifyBody, which shares its fields with
+// the password reset e-mail.
+func newVerifyEmail(to, from, baseURL, token string, expires time.Time) resetEmail {
+	return resetEmail{
+		To:      to,
+		From:    from,
+		Subject: "NFL Pick-Em E-mail Verification",
+		Link:    fmt.Sprintf("%s/profile.html?verify=%s", baseURL, token),
+		Expires: expires.Format(time.RFC1123),
+	}
+}
+
 var emailBody = `
 To: {{.To}}
 From: {{.From}}
@@ -108,3 +225,33 @@ Good luck!
 
 -Kyle Ames Bot
 `
+
+var resetBody = `
+To: {{.To}}
+From: {{.From}}
+Subject: {{.Subject}}
+
+Somebody asked to reset the password for your NFL Pick-Em account. If it wasn't you, you can ignore this e-mail.
+
+To choose a new password, visit the link below before {{.Expires}}:
+
+{{.Link}}
+
+The link can only be used once.
+
+-Kyle Ames Bot
+`
+
+var verifyBody = `
+To: {{.To}}
+From: {{.From}}
+Subject: {{.Subject}}
+
+Somebody asked to change the e-mail address of an NFL Pick-Em account to this one. If it wasn't you, you can ignore this e-mail.
+
+To confirm the change, visit the link below before {{.Expires}}:
+
+{{.Link}}
+
+-Kyle Ames Bot
+`
@@ -0,0 +1,75 @@
+package nflpickem
+
+import "time"
+
+// Consensus describes how the pool as a whole picked a single game.
+type Consensus struct {
+	Game  Game          `json:"game"`
+	Home  ConsensusSide `json:"home"`
+	Away  ConsensusSide `json:"away"`
+	Picks int           `json:"picks"`
+}
+
+// ConsensusSide is the distribution of picks and points wagered on one team of a game.
+type ConsensusSide struct {
+	Team          Team    `json:"team"`
+	Picks         int     `json:"picks"`
+	Percentage    float64 `json:"percentage"`
+	Points        int     `json:"points"`
+	AveragePoints float64 `json:"averagePoints"`
+}
+
+// ConsensusFetcher is the interface implemented by types that can calculate the pool's
+// pick distribution for the games of a given week that have already locked.
+type ConsensusFetcher interface {
+	Consensus(t time.Time, year int, week int) ([]Consensus, error)
+}
+
+// NewConsensus tallies the picks made in each of the given results.
+//
+// Results only contain games that have already started, so the distribution of a game
+// is never revealed before it is locked.
+func NewConsensus(results []Result) []Consensus {
+	consensus := make([]Consensus, 0, len(results))
+
+	for _, r := range results {
+		c := Consensus{
+			Game: r.Game,
+			Home: ConsensusSide{Team: r.Game.Home},
+			Away: ConsensusSide{Team: r.Game.Away},
+		}
+
+		for _, p := range r.Picks {
+			switch {
+			case p.Selection.Equal(r.Game.Home):
+				c.Home.Picks++
+				c.Home.Points += p.Points
+			case p.Selection.Equal(r.Game.Away):
+				c.Away.Picks++
+				c.Away.Points += p.Points
+			default:
+				continue
+			}
+			c.Picks++
+		}
+
+		c.Home.summarize(c.Picks)
+		c.Away.summarize(c.Picks)
+
+		consensus = append(consensus, c)
+	}
+
+	return consensus
+}
+
+// summarize fills in the percentage and average points of the side given the total
+// number of picks made for the game.
+func (s *ConsensusSide) summarize(total int) {
+	if total != 0 {
+		s.Percentage = float64(s.Picks) / float64(total) * 100
+	}
+
+	if s.Picks != 0 {
+		s.AveragePoints = float64(s.Points) / float64(s.Picks)
+	}
+}
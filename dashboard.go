@@ -0,0 +1,32 @@
+package nflpickem
+
+import "time"
+
+// Dashboard is everything shown for a week of the pool, as it stood at a single moment.
+//
+// Picks are the picks of the user the dashboard was made for, while Results only reveal
+// the picks of every user for games that have started.
+type Dashboard struct {
+	Current          Week        `json:"current"`
+	Year             int         `json:"year"`
+	Week             int         `json:"week"`
+	Games            []GameState `json:"games"`
+	Picks            PickSet     `json:"picks"`
+	Results          []Result    `json:"results"`
+	WeekTotals       []WeekTotal `json:"weekTotals"`
+	CumulativeTotals []WeekTotal `json:"cumulativeTotals"`
+}
+
+// GameState is a game, along with whether or not picks for it are locked because it
+// has started.
+type GameState struct {
+	Game
+	Locked bool `json:"locked"`
+}
+
+// DashboardFetcher is the interface implemented by types that can build a user's
+// dashboard for a week of the season as of time t. A zero year and week is the
+// current week as of t.
+type DashboardFetcher interface {
+	Dashboard(username string, t time.Time, year int, week int) (Dashboard, error)
+}
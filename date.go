@@ -2,6 +2,9 @@ package nflpickem
 
 import "time"
 
+// SeasonLength is the number of weeks in the regular season.
+const SeasonLength = 17
+
 // Week represents a unique week of the NFL Pickem' Pool
 type Week struct {
 	Year int `json:"year"`
@@ -0,0 +1,51 @@
+package nflpickem
+
+import "errors"
+
+// ErrorKind classifies errors by how the caller can respond to them.
+type ErrorKind int
+
+const (
+	// KindInternal errors are failures of the pool itself, whose details mean nothing to the caller
+	KindInternal ErrorKind = iota
+	// KindNotFound errors report that the requested thing doesn't exist
+	KindNotFound
+	// KindInvalid errors report a request that is malformed or breaks the rules of the pool
+	KindInvalid
+	// KindLocked errors report a change to something that can no longer be changed
+	KindLocked
+	// KindBadCredentials errors report that the caller couldn't be authenticated
+	KindBadCredentials
+	// KindForbidden errors report that the caller isn't allowed to make the request
+	KindForbidden
+	// KindConflict errors report a change that conflicts with what is already stored
+	KindConflict
+)
+
+// Error is an error of the pool that is safe to report to its users.
+//
+// Code identifies the error to programs and never changes, while Message describes it
+// to people.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(kind ErrorKind, code string, message string) error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// KindOf returns the kind of err, which is KindInternal unless err is an *Error.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	return KindInternal
+}
@@ -19,6 +19,26 @@ func (g Game) Equal(other Game) bool {
 		g.Away.Equal(other.Away))
 }
 
+// Final returns whether or not the game has finished and its score has been recorded.
+func (g Game) Final() bool {
+	return g.HomeScore >= 0 && g.AwayScore >= 0
+}
+
+// Winner returns the team that won the game. The zero Team is returned if the game
+// has not finished or ended in a tie.
+func (g Game) Winner() Team {
+	switch {
+	case !g.Final():
+		return Team{}
+	case g.HomeScore > g.AwayScore:
+		return g.Home
+	case g.AwayScore > g.HomeScore:
+		return g.Away
+	default:
+		return Team{}
+	}
+}
+
 // GamesRetriever is the interface implemented by a type that can retrieve NFL game
 // information.
 type GamesRetriever interface {
@@ -26,6 +46,8 @@ type GamesRetriever interface {
 	CumulativeGames(year int, week int) ([]Game, error)
 }
 
+var ErrUnknownGame = newError(KindNotFound, "unknown_game", "unknown game")
+
 type Updater interface {
 	Weeker
 	UpdateGame(week int, year int, homeTeam string, homeScore int, awayScore int) error
@@ -0,0 +1,320 @@
+package nflpickem
+
+import "sort"
+
+// History is a user's performance across every season of the pool.
+type History struct {
+	User        User             `json:"user"`
+	Total       int              `json:"total"`
+	WeeklyWins  int              `json:"weeklyWins"`
+	BestWeek    *WeekTotal       `json:"bestWeek"`
+	WorstWeek   *WeekTotal       `json:"worstWeek"`
+	Seasons     []SeasonHistory  `json:"seasons"`
+	PointValues []PointValueRate `json:"pointValues"`
+}
+
+// SeasonHistory is a user's performance for a single season.
+//
+// Finish is the user's place in the season standings, where users with the same total
+// share a place. For a season in progress it is the user's current place.
+type SeasonHistory struct {
+	Year       int `json:"year"`
+	Total      int `json:"total"`
+	WeeklyWins int `json:"weeklyWins"`
+	Finish     int `json:"finish"`
+}
+
+// PointValueRate is how often a user's picks of a given point value were correct.
+type PointValueRate struct {
+	Points  int     `json:"points"`
+	Picks   int     `json:"picks"`
+	Correct int     `json:"correct"`
+	Rate    float64 `json:"rate"`
+}
+
+// Records are the all-time bests of the pool. Every user sharing a record is listed.
+type Records struct {
+	HighestWeek   []WeekTotal `json:"highestWeek"`
+	HighestSeason []WeekTotal `json:"highestSeason"`
+	LongestStreak []Streak    `json:"longestStreak"`
+}
+
+// Streak is a run of consecutive correct picks by a user, ordered by kickoff.
+type Streak struct {
+	User   User `json:"user"`
+	Length int  `json:"length"`
+	Start  Week `json:"start"`
+	End    Week `json:"end"`
+}
+
+// HistoryFetcher is the interface implemented by types that can summarize the pool
+// across every season in the data source.
+type HistoryFetcher interface {
+	History() ([]History, error)
+	Records() (Records, error)
+}
+
+// NewHistory summarizes every user's performance from the given picks, which may span
+// any number of seasons. Weekly wins and best and worst weeks only consider weeks where
+// every game has finished. Users are sorted by their all-time total.
+func NewHistory(picks PickSet) []History {
+	s := summarize(picks)
+
+	histories := make([]History, 0, len(s.users))
+	for _, u := range s.users {
+		h := History{User: u, Seasons: make([]SeasonHistory, 0), PointValues: make([]PointValueRate, 0)}
+
+		for _, year := range s.years {
+			total, played := s.seasons[year][u.Email]
+			if !played {
+				continue
+			}
+
+			season := SeasonHistory{Year: year, Total: total, Finish: 1}
+			for _, other := range s.seasons[year] {
+				if other > total {
+					season.Finish++
+				}
+			}
+
+			for _, w := range s.completeWeeks(year) {
+				if s.weekHighs[w] > 0 && s.weeks[w][u.Email] == s.weekHighs[w] {
+					season.WeeklyWins++
+				}
+			}
+
+			h.Total += season.Total
+			h.WeeklyWins += season.WeeklyWins
+			h.Seasons = append(h.Seasons, season)
+		}
+
+		for _, w := range s.order {
+			if !s.complete[w] {
+				continue
+			}
+			total, ok := s.weeks[w][u.Email]
+			if !ok {
+				continue
+			}
+
+			wt := WeekTotal{User: u, Year: w.Year, Week: w.Week, Total: total}
+			if h.BestWeek == nil || wt.Total > h.BestWeek.Total {
+				best := wt
+				h.BestWeek = &best
+			}
+			if h.WorstWeek == nil || wt.Total < h.WorstWeek.Total {
+				worst := wt
+				h.WorstWeek = &worst
+			}
+		}
+
+		for _, points := range s.pointValues {
+			r, ok := s.rates[u.Email][points]
+			if !ok {
+				continue
+			}
+			r.Rate = float64(r.Correct) / float64(r.Picks)
+			h.PointValues = append(h.PointValues, r)
+		}
+
+		histories = append(histories, h)
+	}
+
+	sort.SliceStable(histories, func(i, j int) bool {
+		return histories[i].Total > histories[j].Total
+	})
+
+	return histories
+}
+
+// NewRecords finds the all-time records of the pool from the given picks. Only weeks where
+// every game has finished are considered for the highest week.
+func NewRecords(picks PickSet) Records {
+	s := summarize(picks)
+
+	records := Records{
+		HighestWeek:   make([]WeekTotal, 0),
+		HighestSeason: make([]WeekTotal, 0),
+		LongestStreak: make([]Streak, 0),
+	}
+
+	best := -1
+	for _, w := range s.order {
+		if !s.complete[w] {
+			continue
+		}
+		for _, u := range s.users {
+			total, ok := s.weeks[w][u.Email]
+			if !ok || total < best {
+				continue
+			}
+			if total > best {
+				best = total
+				records.HighestWeek = records.HighestWeek[:0]
+			}
+			records.HighestWeek = append(records.HighestWeek, WeekTotal{User: u, Year: w.Year, Week: w.Week, Total: total})
+		}
+	}
+
+	best = -1
+	for _, year := range s.years {
+		for _, u := range s.users {
+			total, ok := s.seasons[year][u.Email]
+			if !ok || total < best {
+				continue
+			}
+			if total > best {
+				best = total
+				records.HighestSeason = records.HighestSeason[:0]
+			}
+			records.HighestSeason = append(records.HighestSeason, WeekTotal{User: u, Year: year, Total: total})
+		}
+	}
+
+	longest := 1
+	for _, u := range s.users {
+		streak := s.streaks[u.Email]
+		if streak.Length < longest {
+			continue
+		}
+		if streak.Length > longest {
+			longest = streak.Length
+			records.LongestStreak = records.LongestStreak[:0]
+		}
+		records.LongestStreak = append(records.LongestStreak, streak)
+	}
+
+	return records
+}
+
+// summary is the intermediate aggregation of picks shared by NewHistory and NewRecords.
+type summary struct {
+	users       []User
+	years       []int
+	order       []Week
+	pointValues []int
+	weeks       map[Week]map[string]int
+	weekHighs   map[Week]int
+	complete    map[Week]bool
+	seasons     map[int]map[string]int
+	rates       map[string]map[int]PointValueRate
+	streaks     map[string]Streak
+}
+
+func summarize(picks PickSet) summary {
+	s := summary{
+		weeks:     make(map[Week]map[string]int),
+		weekHighs: make(map[Week]int),
+		complete:  make(map[Week]bool),
+		seasons:   make(map[int]map[string]int),
+		rates:     make(map[string]map[int]PointValueRate),
+		streaks:   make(map[string]Streak),
+	}
+
+	sorted := make(PickSet, len(picks))
+	copy(sorted, picks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Game.Date.Before(sorted[j].Game.Date)
+	})
+
+	seenUsers := make(map[string]bool)
+	seenPoints := make(map[int]bool)
+	current := make(map[string]Streak)
+
+	for _, p := range sorted {
+		email := p.User.Email
+		w := Week{Year: p.Game.Year, Week: p.Game.Week}
+
+		if !seenUsers[email] {
+			seenUsers[email] = true
+			s.users = append(s.users, p.User)
+			s.rates[email] = make(map[int]PointValueRate)
+		}
+
+		if _, ok := s.weeks[w]; !ok {
+			s.weeks[w] = make(map[string]int)
+			s.complete[w] = true
+			s.order = append(s.order, w)
+		}
+		if _, ok := s.seasons[w.Year]; !ok {
+			s.seasons[w.Year] = make(map[string]int)
+			s.years = append(s.years, w.Year)
+		}
+
+		// List the user for the week and season even if they never score
+		s.weeks[w][email] += 0
+		s.seasons[w.Year][email] += 0
+
+		if !p.Game.Final() {
+			s.complete[w] = false
+			continue
+		}
+
+		correct := p.Correct()
+		if correct {
+			s.weeks[w][email] += p.Points
+			s.seasons[w.Year][email] += p.Points
+		}
+
+		if p.Points > 0 {
+			if !seenPoints[p.Points] {
+				seenPoints[p.Points] = true
+				s.pointValues = append(s.pointValues, p.Points)
+			}
+			r := s.rates[email][p.Points]
+			r.Points = p.Points
+			r.Picks++
+			if correct {
+				r.Correct++
+			}
+			s.rates[email][p.Points] = r
+		}
+
+		streak := current[email]
+		if !correct {
+			delete(current, email)
+			continue
+		}
+		if streak.Length == 0 {
+			streak = Streak{User: p.User, Start: w}
+		}
+		streak.Length++
+		streak.End = w
+		current[email] = streak
+
+		if streak.Length > s.streaks[email].Length {
+			s.streaks[email] = streak
+		}
+	}
+
+	for w, totals := range s.weeks {
+		for _, total := range totals {
+			if total > s.weekHighs[w] {
+				s.weekHighs[w] = total
+			}
+		}
+	}
+
+	sort.Ints(s.years)
+	sort.Ints(s.pointValues)
+	sort.SliceStable(s.order, func(i, j int) bool {
+		if s.order[i].Year != s.order[j].Year {
+			return s.order[i].Year < s.order[j].Year
+		}
+		return s.order[i].Week < s.order[j].Week
+	})
+
+	return s
+}
+
+// completeWeeks returns the weeks of the given year where every game has finished.
+func (s summary) completeWeeks(year int) []Week {
+	weeks := make([]Week, 0)
+	for _, w := range s.order {
+		if w.Year == year && s.complete[w] {
+			weeks = append(weeks, w)
+		}
+	}
+
+	return weeks
+}
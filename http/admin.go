@@ -0,0 +1,154 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// adminUsers lists all users of the pool, OR adds a new user based on the provided
+// form values.
+//
+// Form Values (POST):
+//	firstName: Required
+//	lastName: Required
+//	email: Required
+//	password: Required
+//	admin: ["true", "false"], Optional
+func adminUsers(db nflpickem.UserManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			users, err := db.Users()
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			WriteJSON(w, users)
+		case "POST":
+			first, last := r.FormValue("firstName"), r.FormValue("lastName")
+			email, password := r.FormValue("email"), r.FormValue("password")
+			if first == "" || last == "" || email == "" || password == "" {
+				WriteJSONError(w, http.StatusBadRequest, "firstName, lastName, email, and password are required")
+				return
+			}
+
+			admin, err := formBool(r, "admin")
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			err = db.AddUser(first, last, email, password, admin)
+			if err != nil {
+				log.Println(err)
+				WriteJSONError(w, http.StatusInternalServerError, "unable to add user")
+				return
+			}
+
+			WriteJSONSuccess(w, fmt.Sprintf("Successfully added user %s", email))
+		default:
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only GET or POST allowed")
+		}
+	}
+}
+
+// adminDisableUser disables or re-enables a user.
+//
+// Form Values:
+//	email: Required
+//	disabled: ["true", "false"], defaults to true
+func adminDisableUser(db nflpickem.UserManager) http.HandlerFunc {
+	return adminUpdateUser("disabled", true, db.DisableUser, "Successfully updated disabled status for user %s")
+}
+
+// adminPromoteUser grants or revokes administrator privileges for a user.
+//
+// Form Values:
+//	email: Required
+//	admin: ["true", "false"], defaults to true
+func adminPromoteUser(db nflpickem.UserManager) http.HandlerFunc {
+	return adminUpdateUser("admin", true, db.PromoteUser, "Successfully updated admin status for user %s")
+}
+
+// adminUpdateUser builds a handler that sets a boolean property of the user named by the
+// email form value using update.
+func adminUpdateUser(field string, def bool, update func(string, bool) error, success string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		email := r.FormValue("email")
+		if email == "" {
+			WriteJSONError(w, http.StatusBadRequest, "email is required")
+			return
+		}
+
+		value := def
+		if r.FormValue(field) != "" {
+			var err error
+			value, err = formBool(r, field)
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		err := update(email, value)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, fmt.Sprintf(success, email))
+	}
+}
+
+// adminResetPassword sets a new password for a user without requiring the old one.
+//
+// Form Values:
+//	email: Required
+//	password: Required
+func adminResetPassword(db nflpickem.UserManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		email, password := r.FormValue("email"), r.FormValue("password")
+		if email == "" || password == "" {
+			WriteJSONError(w, http.StatusBadRequest, "email and password are required")
+			return
+		}
+
+		err := db.ResetPassword(email, password)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, fmt.Sprintf("Successfully reset password for user %s", email))
+	}
+}
+
+// formBool parses the named form value as a boolean, treating a missing value as false.
+func formBool(r *http.Request, name string) (bool, error) {
+	v := r.FormValue(name)
+	if v == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// apiTokenTouchInterval limits how often an API token's last use is written to the datastore.
+const apiTokenTouchInterval = time.Minute
+
+var errInsufficientScope = &nflpickem.Error{Kind: nflpickem.KindForbidden, Code: "insufficient_scope", Message: "API token scope does not allow this request"}
+
+// bearerToken extracts the API token from the request's Authorization header, if present.
+func bearerToken(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+
+	return token, token != ""
+}
+
+// verifyAPIToken validates the API token presented in the request, returning the current
+// information for its user. Requests that may change state are only allowed if the token's
+// scope allows the given scope; reads are allowed for any scope.
+//
+// API tokens aren't sent automatically by a browser, so no CSRF token is required.
+func (s *Server) verifyAPIToken(r *http.Request, token string, scope nflpickem.TokenScope) (nflpickem.User, error) {
+	a, err := s.db.APIToken(token)
+	if err != nil {
+		return nflpickem.User{}, err
+	}
+
+	if a.User.Disabled {
+		return nflpickem.User{}, nflpickem.ErrUserDisabled
+	}
+
+	now := s.time.Now()
+	if now.Sub(a.LastUsed) > apiTokenTouchInterval {
+		err := s.db.TouchAPIToken(a.ID, now)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return a.User, nil
+	}
+
+	if !a.Scope.Allows(scope) {
+		return nflpickem.User{}, errInsufficientScope
+	}
+
+	return a.User, nil
+}
+
+// createdAPIToken is a newly created API token, which is never available again.
+type createdAPIToken struct {
+	Name  string               `json:"name"`
+	Scope nflpickem.TokenScope `json:"scope"`
+	Token string               `json:"token"`
+}
+
+// apiTokens lists the logged in user's API tokens, OR creates a new one. The new token is
+// only ever returned in the response to its creation.
+//
+// Form Values (POST):
+//	name: Required
+//	scope: ["read-only", "picks:write"], Required
+func apiTokens(db nflpickem.APITokenManager, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+			tokens, err := db.APITokens(user.Email)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			WriteJSON(w, tokens)
+		case "POST":
+			name := r.FormValue("name")
+			if name == "" {
+				WriteJSONError(w, http.StatusBadRequest, "name is required")
+				return
+			}
+
+			scope, err := nflpickem.ParseTokenScope(r.FormValue("scope"))
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, "scope must be read-only or picks:write")
+				return
+			}
+
+			token, err := db.CreateAPIToken(user.Email, name, scope, t.Now())
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			created := createdAPIToken{
+				name,
+				scope,
+				token,
+			}
+
+			WriteJSON(w, created)
+		default:
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only GET or POST allowed")
+		}
+	}
+}
+
+// revokeAPIToken deletes one of the logged in user's API tokens.
+//
+// Form Values:
+//	id: Required
+func revokeAPIToken(db nflpickem.APITokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "id must be integer")
+			return
+		}
+
+		err = db.RevokeAPIToken(user.Email, id)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, fmt.Sprintf("Successfully revoked API token %d", id))
+	}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// finalWeekMaxAge is how long a response for a week where every game is final may be reused
+// without asking the server again. Scores are rarely corrected after the fact, and a
+// correction still changes the ETag that clients revalidate with.
+const finalWeekMaxAge = time.Hour
+
+// cacheWeek allows responses about a single week of the season to be cached, until the
+// data they are built from changes.
+//
+// The ETag and Last-Modified headers come from the datastore's version, so a client that
+// already has the current response is answered with 304 Not Modified without building it
+// again. Weeks where every game is final may be reused for a while; the live week must be
+// revalidated on every request.
+func (s *Server) cacheWeek(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			h(w, r)
+			return
+		}
+
+		year, week, err := weekParams(r)
+		if err != nil {
+			h(w, r)
+			return
+		}
+
+		v, err := s.db.Version(s.time.Now())
+		if err != nil {
+			log.Println(err)
+			h(w, r)
+			return
+		}
+
+		final, err := s.db.WeekFinal(year, week)
+		if err != nil {
+			log.Println(err)
+			h(w, r)
+			return
+		}
+
+		etag := fmt.Sprintf(`W/"%d-%d"`, v.Changes, v.Started)
+		modified := v.Modified.UTC().Truncate(time.Second)
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		if final {
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(finalWeekMaxAge/time.Second)))
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+
+		if notModified(r, etag, modified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+// notModified returns whether the client's cached copy, described by its conditional
+// request headers, is still current. If-None-Match takes precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !modified.After(since)
+}
+
+// noCache removes any caching headers set for a response, so that errors are never cached.
+func noCache(w http.ResponseWriter) {
+	w.Header().Del("ETag")
+	w.Header().Del("Last-Modified")
+	w.Header().Set("Cache-Control", "no-store")
+}
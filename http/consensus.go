@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// Consensus returns the percentage of the pool that took each side of every locked game
+// in the given week, along with the points wagered on each team.
+//
+// URL Parameters:
+//	year: Specifies the current year, Required
+//	week: Specifies the current week, Required
+func consensus(db nflpickem.ConsensusFetcher, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		year, week, err := weekParams(r)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		consensus, err := db.Consensus(t.Now(), year, week)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, consensus)
+	}
+}
@@ -12,7 +12,7 @@ func currentWeek(db nflpickem.Weeker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		week, err := db.CurrentWeek(time.Now())
 		if err != nil {
-			WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			WriteError(w, err)
 		}
 
 		WriteJSON(w, week)
@@ -23,7 +23,7 @@ func years(db nflpickem.DataSummarizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		years, err := db.Years()
 		if err != nil {
-			WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			WriteError(w, err)
 		}
 
 		y := struct {
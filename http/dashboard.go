@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// dashboard returns the logged in user's dashboard for a week of the NFL season: the
+// current week, the week's games and whether they are locked, the user's picks, revealed
+// results, and week and cumulative totals, all as of the same moment.
+//
+// URL Parameters:
+//	year: Specifies the year, Optional (default current week)
+//	week: Specifies the week, Required with year
+func dashboard(db nflpickem.DashboardFetcher, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		var year, week int
+		if _, ok := pathParam(r, "year"); ok || r.FormValue("year") != "" || r.FormValue("week") != "" {
+			year, week, err = weekParams(r)
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		d, err := db.Dashboard(user.Email, t.Now(), year, week)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, d)
+	}
+}
@@ -3,7 +3,6 @@ package http
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/ameske/nfl-pickem"
 )
@@ -18,17 +17,9 @@ func games(db nflpickem.GamesRetriever) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var games []nflpickem.Game
 
-		yearStr := r.FormValue("year")
-		year, err := strconv.Atoi(yearStr)
+		year, week, err := weekParams(r)
 		if err != nil {
-			WriteJSONError(w, http.StatusBadRequest, "year query parameter must be integer")
-			return
-		}
-
-		weekStr := r.FormValue("week")
-		week, err := strconv.Atoi(weekStr)
-		if err != nil {
-			WriteJSONError(w, http.StatusBadRequest, "week query parameter must be integer")
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
@@ -45,7 +36,7 @@ func games(db nflpickem.GamesRetriever) http.HandlerFunc {
 		}
 
 		if err != nil {
-			WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			WriteError(w, err)
 			return
 		}
 
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// History returns every user's all-time performance across every season of the pool.
+func history(db nflpickem.HistoryFetcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		history, err := db.History()
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, history)
+	}
+}
+
+// Records returns the all-time records of the pool, such as the highest week score
+// and the longest streak of correct picks.
+func records(db nflpickem.HistoryFetcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		records, err := db.Records()
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, records)
+	}
+}
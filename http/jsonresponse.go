@@ -2,8 +2,12 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
+
+	"github.com/ameske/nfl-pickem"
 )
 
 func WriteJSON(w http.ResponseWriter, response interface{}) {
@@ -16,8 +20,11 @@ func WriteJSON(w http.ResponseWriter, response interface{}) {
 	}
 }
 
+// statusResponse reports the outcome of a request. Failures also carry a code that
+// identifies the error to programs and never changes.
 type statusResponse struct {
 	Status  string `json:"status"`
+	Code    string `json:"code,omitempty"`
 	Message string `json:"message"`
 }
 
@@ -32,12 +39,52 @@ func WriteJSONSuccess(w http.ResponseWriter, response string) {
 	}
 }
 
+// WriteJSONError reports a failure with the given status, identified by the code for the
+// status, such as "not_found".
 func WriteJSONError(w http.ResponseWriter, code int, response string) {
-	w.WriteHeader(code)
+	writeJSONFailure(w, code, statusCode(code), response)
+}
+
+// errorStatus is the HTTP status reported for each kind of error.
+var errorStatus = map[nflpickem.ErrorKind]int{
+	nflpickem.KindNotFound:       http.StatusNotFound,
+	nflpickem.KindInvalid:        http.StatusBadRequest,
+	nflpickem.KindLocked:         http.StatusConflict,
+	nflpickem.KindBadCredentials: http.StatusUnauthorized,
+	nflpickem.KindForbidden:      http.StatusForbidden,
+	nflpickem.KindConflict:       http.StatusConflict,
+}
+
+// WriteError reports err with the status for its kind and its code. Internal errors are
+// logged, but their details are never sent to the client.
+func WriteError(w http.ResponseWriter, err error) {
+	var e *nflpickem.Error
+	if !errors.As(err, &e) || e.Kind == nflpickem.KindInternal {
+		// The cause is logged along with the request when the server is logging requests
+		if rec, ok := w.(*statusRecorder); ok {
+			rec.err = err
+		} else {
+			log.Println(err)
+		}
+		WriteJSONError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	writeJSONFailure(w, errorStatus[e.Kind], e.Code, e.Message)
+}
+
+// statusCode returns the code identifying failures reported with the given status.
+func statusCode(status int) string {
+	return strings.ToLower(strings.Replace(http.StatusText(status), " ", "_", -1))
+}
+
+func writeJSONFailure(w http.ResponseWriter, status int, code string, message string) {
+	noCache(w)
+	w.WriteHeader(status)
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "\t")
 
-	err := enc.Encode(&statusResponse{Status: "failed", Message: response})
+	err := enc.Encode(&statusResponse{Status: "failed", Code: code, Message: message})
 	if err != nil {
 		log.Println(err)
 	}
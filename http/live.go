@@ -0,0 +1,407 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+const (
+	// liveBuffer is how many events a client may fall behind before it is disconnected
+	liveBuffer = 32
+	// liveKeepAlive is how often an idle stream is written to, so that proxies keep it open
+	liveKeepAlive = 30 * time.Second
+	// liveRetry is how long, in milliseconds, a disconnected browser waits to reconnect
+	liveRetry = 5000
+)
+
+// Game statuses reported in live events
+const (
+	statusScheduled  = "scheduled"
+	statusInProgress = "in-progress"
+	statusFinal      = "final"
+)
+
+// liveEvent is a change to the pool that is pushed to live clients.
+type liveEvent struct {
+	name string
+	year int
+	week int
+	data interface{}
+}
+
+// gameEvent is the data of the "score" and "status" events, sent when a game's score or
+// status changes.
+type gameEvent struct {
+	Game   nflpickem.Game `json:"game"`
+	Status string         `json:"status"`
+}
+
+// totalsEvent is the data of the "totals" event, sent with the recomputed totals for a
+// week whenever a game of the week changes.
+type totalsEvent struct {
+	Year   int                   `json:"year"`
+	Week   int                   `json:"week"`
+	Totals []nflpickem.WeekTotal `json:"totals"`
+}
+
+// gameStatus returns the status of the game at time t.
+func gameStatus(g nflpickem.Game, t time.Time) string {
+	switch {
+	case g.Final():
+		return statusFinal
+	case t.Before(g.Date):
+		return statusScheduled
+	default:
+		return statusInProgress
+	}
+}
+
+// gameKey identifies a game by its week and home team.
+type gameKey struct {
+	year int
+	week int
+	home string
+}
+
+// publishedStatuses remembers the status last published for each game, so that a change
+// of status is noticed even when it comes with the passing of time, such as a game kicking
+// off, rather than with a new score.
+type publishedStatuses struct {
+	mu       sync.Mutex
+	statuses map[gameKey]string
+}
+
+func newPublishedStatuses() *publishedStatuses {
+	return &publishedStatuses{statuses: make(map[gameKey]string)}
+}
+
+// swap records status as the game's published status, returning the one it replaces. A
+// game that hasn't been published yet is assumed to have had the given previous status.
+func (p *publishedStatuses) swap(k gameKey, status string, previous string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if published, ok := p.statuses[k]; ok {
+		previous = published
+	}
+	p.statuses[k] = status
+
+	return previous
+}
+
+// hub fans live events out to every subscribed client.
+//
+// Publishing never waits on a client. A client that falls too far behind has its channel
+// closed, and is expected to reconnect and reload what it displays. Once the hub is closed,
+// every channel is closed, including those subscribed afterwards.
+type hub struct {
+	mu          sync.Mutex
+	subscribers map[chan liveEvent]bool
+	closed      bool
+}
+
+func newHub() *hub {
+	return &hub{subscribers: make(map[chan liveEvent]bool)}
+}
+
+// subscribe returns a channel that receives every event published until unsubscribe is
+// called with it.
+func (h *hub) subscribe() chan liveEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := make(chan liveEvent, liveBuffer)
+	if h.closed {
+		close(c)
+		return c
+	}
+	h.subscribers[c] = true
+
+	return c
+}
+
+// count returns the number of subscribed clients.
+func (h *hub) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers)
+}
+
+func (h *hub) unsubscribe(c chan liveEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[c] {
+		delete(h.subscribers, c)
+		close(c)
+	}
+}
+
+// close ends every subscription, so that the server can shut down without waiting on
+// clients that would otherwise stay connected indefinitely.
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for c := range h.subscribers {
+		delete(h.subscribers, c)
+		close(c)
+	}
+}
+
+func (h *hub) publish(e liveEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.subscribers {
+		select {
+		case c <- e:
+		default:
+			delete(h.subscribers, c)
+			close(c)
+		}
+	}
+}
+
+// liveStore is the interface that defines the ability to update games, and retrieve what
+// changes as a result.
+type liveStore interface {
+	nflpickem.Updater
+	nflpickem.GamesRetriever
+	nflpickem.WeekTotalFetcher
+}
+
+// liveUpdater updates games, publishing the game's new score, along with the recomputed
+// totals for its week, to live clients whenever the score changes, and the game's status
+// whenever it differs from the status last published.
+type liveUpdater struct {
+	liveStore
+	hub      *hub
+	statuses *publishedStatuses
+	time     TimeSource
+}
+
+// Updater returns an Updater for the server's datastore that pushes every change it makes
+// to clients following the live event stream.
+func (s *Server) Updater() nflpickem.Updater {
+	return liveUpdater{liveStore: s.db, hub: s.hub, statuses: s.statuses, time: s.time}
+}
+
+func (u liveUpdater) UpdateGame(week int, year int, homeTeam string, homeScore int, awayScore int) error {
+	before, found := u.game(year, week, homeTeam)
+
+	err := u.liveStore.UpdateGame(week, year, homeTeam, homeScore, awayScore)
+	if err != nil {
+		return err
+	}
+
+	after, ok := u.game(year, week, homeTeam)
+	if !ok {
+		return nil
+	}
+
+	now := u.time.Now()
+	event := gameEvent{Game: after, Status: gameStatus(after, now)}
+
+	var previous string
+	if found {
+		previous = gameStatus(before, now)
+	}
+
+	scoreChanged := !found || before.HomeScore != after.HomeScore || before.AwayScore != after.AwayScore
+	statusChanged := u.statuses.swap(gameKey{year, week, homeTeam}, event.Status, previous) != event.Status
+
+	if scoreChanged {
+		u.hub.publish(liveEvent{name: "score", year: year, week: week, data: event})
+	}
+
+	if statusChanged {
+		u.hub.publish(liveEvent{name: "status", year: year, week: week, data: event})
+	}
+
+	if !scoreChanged {
+		return nil
+	}
+
+	totals, err := u.WeekTotals(year, week)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	u.hub.publish(liveEvent{name: "totals", year: year, week: week, data: totalsEvent{year, week, totals}})
+
+	return nil
+}
+
+// game returns the game of the given week played at homeTeam's stadium, if it can be found.
+func (u liveUpdater) game(year int, week int, homeTeam string) (nflpickem.Game, bool) {
+	games, err := u.WeekGames(year, week)
+	if err != nil {
+		log.Println(err)
+		return nflpickem.Game{}, false
+	}
+
+	for _, g := range games {
+		if g.Home.Nickname == homeTeam {
+			return g, true
+		}
+	}
+
+	return nflpickem.Game{}, false
+}
+
+// liveEvents streams changes to games and week totals as Server-Sent Events, for as long
+// as the client stays connected.
+//
+// Events:
+//	score: a game's score changed, with gameEvent data
+//	status: a game's status changed, such as kicking off or becoming final, with gameEvent data
+//	totals: the totals of a week were recomputed, with totalsEvent data
+//
+// URL Parameters:
+//	year: only send events for the given year, Optional
+//	week: only send events for the given week, Optional
+func liveEvents(h *hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			WriteJSONError(w, http.StatusInternalServerError, "streaming not supported")
+			return
+		}
+
+		year, week, err := eventFilter(r)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// The stream outlives the server's read and write timeouts by design
+		rc := http.NewResponseController(w)
+		err = rc.SetReadDeadline(time.Time{})
+		if err == nil {
+			err = rc.SetWriteDeadline(time.Time{})
+		}
+		if err != nil {
+			log.Println(err)
+		}
+
+		events := h.subscribe()
+		defer h.unsubscribe(events)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: %d\n\n", liveRetry)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(liveKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+
+				if (year != 0 && e.year != year) || (week != 0 && e.week != week) {
+					continue
+				}
+
+				data, err := json.Marshal(e.data)
+				if err != nil {
+					log.Println(err)
+					continue
+				}
+
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, data)
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// eventFilter parses the optional year and week that a client wants events for. Zero
+// means events for any year or week.
+func eventFilter(r *http.Request) (year int, week int, err error) {
+	if _, ok := pathParam(r, "year"); ok || r.FormValue("year") != "" {
+		year, err = intParam(r, "year")
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if _, ok := pathParam(r, "week"); ok || r.FormValue("week") != "" {
+		week, err = intParam(r, "week")
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return year, week, nil
+}
+
+// adminScoreGame records the score of a game, pushing the change to live clients.
+//
+// Form Values:
+//	year: Required
+//	week: Required
+//	home: nickname of the home team, Required
+//	homeScore: Required
+//	awayScore: Required
+func adminScoreGame(u nflpickem.Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		year, week, err := weekParams(r)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		home := r.FormValue("home")
+		if home == "" {
+			WriteJSONError(w, http.StatusBadRequest, "home is required")
+			return
+		}
+
+		homeScore, err := intParam(r, "homeScore")
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		awayScore, err := intParam(r, "awayScore")
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = u.UpdateGame(week, year, home, homeScore, awayScore)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, fmt.Sprintf("Successfully updated the %s game", home))
+	}
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// stubLiveStore holds the games of a single week.
+type stubLiveStore struct {
+	liveStore
+	games []nflpickem.Game
+}
+
+func (s *stubLiveStore) UpdateGame(week int, year int, homeTeam string, homeScore int, awayScore int) error {
+	for i, g := range s.games {
+		if g.Home.Nickname == homeTeam {
+			s.games[i].HomeScore, s.games[i].AwayScore = homeScore, awayScore
+			return nil
+		}
+	}
+
+	return nflpickem.ErrUnknownGame
+}
+
+func (s *stubLiveStore) WeekGames(year int, week int) ([]nflpickem.Game, error) {
+	return append([]nflpickem.Game{}, s.games...), nil
+}
+
+func (s *stubLiveStore) WeekTotals(year int, week int) ([]nflpickem.WeekTotal, error) {
+	return []nflpickem.WeekTotal{}, nil
+}
+
+func TestLiveUpdaterStatus(t *testing.T) {
+	kickoff := time.Date(2017, time.September, 10, 13, 0, 0, 0, time.UTC)
+	store := &stubLiveStore{games: []nflpickem.Game{{
+		Year:      2017,
+		Week:      1,
+		Date:      kickoff,
+		Home:      nflpickem.Team{City: "Buffalo", Nickname: "Bills"},
+		Away:      nflpickem.Team{City: "New York", Nickname: "Jets"},
+		HomeScore: -1,
+		AwayScore: -1,
+	}}}
+
+	h := newHub()
+	events := h.subscribe()
+	u := liveUpdater{liveStore: store, hub: h, statuses: newPublishedStatuses()}
+
+	updates := []struct {
+		name      string
+		at        time.Time
+		homeScore int
+		awayScore int
+		events    []string
+		status    string
+	}{
+		{"before kickoff", kickoff.Add(-time.Hour), -1, -1, nil, ""},
+		{"after kickoff", kickoff.Add(time.Minute), -1, -1, []string{"status"}, statusInProgress},
+		{"still in progress", kickoff.Add(time.Hour), -1, -1, nil, ""},
+		{"final", kickoff.Add(3 * time.Hour), 21, 12, []string{"score", "status", "totals"}, statusFinal},
+		{"score corrected", kickoff.Add(4 * time.Hour), 24, 12, []string{"score", "totals"}, statusFinal},
+		{"reopened", kickoff.Add(5 * time.Hour), -1, -1, []string{"score", "status", "totals"}, statusInProgress},
+	}
+
+	for _, update := range updates {
+		u.time = fixedTime(update.at)
+
+		err := u.UpdateGame(1, 2017, "Bills", update.homeScore, update.awayScore)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var published []string
+		for len(events) > 0 {
+			e := <-events
+			published = append(published, e.name)
+
+			if e.name == "status" {
+				if status := e.data.(gameEvent).Status; status != update.status {
+					t.Errorf("%s: published status %q, expected %q", update.name, status, update.status)
+				}
+			}
+		}
+
+		if len(published) != len(update.events) {
+			t.Errorf("%s: published %v, expected %v", update.name, published, update.events)
+			continue
+		}
+
+		for i := range published {
+			if published[i] != update.events[i] {
+				t.Errorf("%s: published %v, expected %v", update.name, published, update.events)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// errLockedOut is returned when a login is refused because of too many recent failures.
+type errLockedOut struct {
+	until time.Time
+}
+
+func (e errLockedOut) Error() string {
+	return "too many failed logins, try again later"
+}
+
+// checkCredentials verifies the username and password, along with the second factor if
+// the user has enabled it, refusing to check them at all while the account or the client's
+// IP address is locked out.
+func (s *Server) checkCredentials(r *http.Request, username string, password string) (nflpickem.User, error) {
+	return s.guardLogin(r, username, func() (nflpickem.User, error) {
+		user, err := s.db.CheckCredentials(username, password)
+		if err != nil {
+			return nflpickem.User{}, err
+		}
+
+		return user, s.verifySecondFactor(r, user)
+	})
+}
+
+// guardLogin runs check, which logs in the given user, unless the account or the client's
+// IP address is locked out. Failures are recorded against both, and a success clears the
+// account's failures. A missing second factor isn't a failure, since the client is
+// expected to retry with one.
+func (s *Server) guardLogin(r *http.Request, username string, check func() (nflpickem.User, error)) (user nflpickem.User, err error) {
+	defer func() {
+		if err != nil && err != nflpickem.ErrTOTPRequired {
+			s.metrics.loginFailure(err)
+		}
+	}()
+
+	now := s.time.Now()
+	ip := s.clientIP(r)
+
+	account, err := s.db.Lockout(nflpickem.AccountLockout, username)
+	if err != nil {
+		return nflpickem.User{}, err
+	}
+
+	address, err := s.db.Lockout(nflpickem.AddressLockout, ip)
+	if err != nil {
+		return nflpickem.User{}, err
+	}
+
+	if account.Locked(now) || address.Locked(now) {
+		until := account.LockedUntil
+		if address.LockedUntil.After(until) {
+			until = address.LockedUntil
+		}
+		return nflpickem.User{}, errLockedOut{until: until}
+	}
+
+	user, err = check()
+	if err == nflpickem.ErrTOTPRequired {
+		return nflpickem.User{}, err
+	} else if err != nil {
+		for _, l := range []nflpickem.Lockout{s.opts.AccountLockout.Fail(account, now), s.opts.AddressLockout.Fail(address, now)} {
+			if err := s.db.SaveLockout(l); err != nil {
+				log.Println(err)
+			}
+		}
+		return nflpickem.User{}, err
+	}
+
+	if account.Failures > 0 {
+		err = s.db.ClearLockout(nflpickem.AccountLockout, username)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	return user, nil
+}
+
+// writeLockedOut responds to a login refused by a lockout, telling the client when to retry.
+func writeLockedOut(w http.ResponseWriter, err errLockedOut, now time.Time) {
+	retry := int(math.Ceil(err.until.Sub(now).Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	WriteJSONError(w, http.StatusTooManyRequests, err.Error())
+}
+
+// clientIP returns the IP address of the client that made the request.
+//
+// When the server trusts its reverse proxy, the last address in the X-Forwarded-For header
+// is used, since that is the one the proxy added itself.
+func (s *Server) clientIP(r *http.Request) string {
+	if s.opts.TrustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addrs := strings.Split(forwarded, ",")
+			return strings.TrimSpace(addrs[len(addrs)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// adminLockouts lists every account and IP address with recent failed logins.
+func adminLockouts(db nflpickem.LockoutManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lockouts, err := db.Lockouts()
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, lockouts)
+	}
+}
+
+// adminClearLockout forgets the failed logins of an account or IP address, lifting any lockout.
+//
+// Form Values:
+//	kind: ["account", "ip"], Required
+//	key: the username or IP address, Required
+func adminClearLockout(db nflpickem.LockoutManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		kind := nflpickem.LockoutKind(r.FormValue("kind"))
+		if kind != nflpickem.AccountLockout && kind != nflpickem.AddressLockout {
+			WriteJSONError(w, http.StatusBadRequest, "kind must be account or ip")
+			return
+		}
+
+		key := r.FormValue("key")
+		if key == "" {
+			WriteJSONError(w, http.StatusBadRequest, "key is required")
+			return
+		}
+
+		err := db.ClearLockout(kind, key)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, "Successfully cleared lockout for "+key)
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+func TestLoginLockout(t *testing.T) {
+	start := time.Date(2017, time.September, 10, 13, 0, 0, 0, time.UTC)
+	policy := nflpickem.LockoutPolicy{Threshold: 2, Base: time.Minute, Max: time.Hour, Reset: time.Hour}
+
+	db := newStubService(nflpickem.User{FirstName: "Alice", Email: "alice@example.com"})
+	db.passwords["alice@example.com"] = "correct"
+	s := newTestServer(t, db, start, Options{AccountLockout: policy, AddressLockout: policy})
+
+	login := func(password string) *http.Response {
+		r := httptest.NewRequest("POST", "/api/login", nil)
+		r.SetBasicAuth("alice@example.com", password)
+		return serve(s, r)
+	}
+
+	for i := 0; i < policy.Threshold; i++ {
+		if resp := login("wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("failure %d returned %d, expected %d", i+1, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+
+	resp := login("correct")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("login while locked out returned %d, expected %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+
+	if retry := resp.Header.Get("Retry-After"); retry != "60" {
+		t.Errorf("Retry-After is %q, expected 60", retry)
+	}
+
+	s.time = fixedTime(start.Add(policy.Base))
+
+	if resp := login("correct"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("login after the lockout expired returned %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if _, ok := db.lockouts[nflpickem.AccountLockout]["alice@example.com"]; ok {
+		t.Error("account's failures not cleared by a successful login")
+	}
+
+	if l := db.lockouts[nflpickem.AddressLockout]["192.0.2.1"]; l.Failures != policy.Threshold {
+		t.Errorf("address has %d failures, expected a successful login to leave them", l.Failures)
+	}
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// requestIDHeader carries the ID that a request is logged with, so that a client or proxy
+// can report it along with a problem.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the request IDs accepted from a trusted proxy
+const maxRequestIDLength = 64
+
+// requestInfo is filled in while a request is handled, so that it can be logged and
+// measured once the request has been answered.
+type requestInfo struct {
+	id    string
+	route string
+	user  string
+}
+
+type requestInfoKey struct{}
+
+// currentRequestInfo returns the information being gathered about the request with the
+// given context, or nil if the request isn't being logged.
+func currentRequestInfo(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// setRequestUser records the user a request was made by, if the request is being logged.
+func setRequestUser(r *http.Request, email string) {
+	if info := currentRequestInfo(r.Context()); info != nil {
+		info.user = email
+	}
+}
+
+// requestID returns the ID to log the request with. A reverse proxy that the server trusts
+// may assign it; otherwise a new one is generated.
+func (s *Server) requestID(r *http.Request) string {
+	if s.opts.TrustProxy {
+		if id := r.Header.Get(requestIDHeader); validRequestID(id) {
+			return id
+		}
+	}
+
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// validRequestID returns whether id is safe to log and send back to the client.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// logRequest logs a request once it has been answered. Server errors are logged as errors
+// along with their cause, while requests for static files and from monitoring are only
+// logged when debugging.
+func (s *Server) logRequest(r *http.Request, info *requestInfo, rec *statusRecorder, status int, d time.Duration) {
+	level := slog.LevelInfo
+	switch {
+	case status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case info.route == staticRoute, info.route == "/metrics", info.route == "/healthz", info.route == "/readyz":
+		level = slog.LevelDebug
+	}
+
+	if !s.logger.Enabled(r.Context(), level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("request_id", info.id),
+		slog.String("method", r.Method),
+		slog.String("route", info.route),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("duration", d),
+		slog.String("client", s.clientIP(r)),
+	}
+
+	if info.user != "" {
+		attrs = append(attrs, slog.String("user", info.user))
+	}
+
+	if rec.err != nil {
+		attrs = append(attrs, slog.String("error", rec.err.Error()))
+	}
+
+	s.logger.LogAttrs(r.Context(), level, "request", attrs...)
+}
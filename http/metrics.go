@@ -0,0 +1,383 @@
+package http
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request duration histogram
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Routes reported for requests that didn't match an API route
+const (
+	staticRoute    = "static"
+	unmatchedRoute = "unmatched"
+)
+
+// otherMethod is reported for requests with a method that isn't a standard one, so that
+// clients can't create an unbounded number of series
+const otherMethod = "OTHER"
+
+// standardMethods are the request methods counted by name
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodPatch:   true,
+	http.MethodOptions: true,
+}
+
+// requestLabels identify the requests counted together
+type requestLabels struct {
+	route  string
+	method string
+	code   int
+}
+
+// durationLabels identify the requests whose durations are observed together
+type durationLabels struct {
+	route  string
+	method string
+}
+
+// histogram counts observations in cumulative buckets, as Prometheus expects.
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *histogram) observe(v float64) {
+	if h.buckets == nil {
+		h.buckets = make([]uint64, len(durationBuckets))
+	}
+
+	for i, upper := range durationBuckets {
+		if v <= upper {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// metrics collects what the server does, for scraping by Prometheus.
+type metrics struct {
+	mu              sync.Mutex
+	requests        map[requestLabels]uint64
+	durations       map[durationLabels]*histogram
+	loginFailures   map[string]uint64
+	pickSubmissions uint64
+	notifications   map[string]uint64
+	updates         map[string]uint64
+	lastUpdate      time.Time
+	liveSubscribers func() int
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:      make(map[requestLabels]uint64),
+		durations:     make(map[durationLabels]*histogram),
+		loginFailures: make(map[string]uint64),
+		notifications: make(map[string]uint64),
+		updates:       make(map[string]uint64),
+	}
+}
+
+func (m *metrics) observeRequest(route string, method string, code int, d time.Duration) {
+	if !standardMethods[method] {
+		method = otherMethod
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestLabels{route, method, code}]++
+
+	h, ok := m.durations[durationLabels{route, method}]
+	if !ok {
+		h = &histogram{}
+		m.durations[durationLabels{route, method}] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// loginFailure counts a failed login, by the code of the error that caused it.
+func (m *metrics) loginFailure(err error) {
+	reason := "internal"
+
+	var e *nflpickem.Error
+	if _, ok := err.(errLockedOut); ok {
+		reason = "locked_out"
+	} else if errors.As(err, &e) {
+		reason = e.Code
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.loginFailures[reason]++
+}
+
+func (m *metrics) pickSubmission() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pickSubmissions++
+}
+
+func (m *metrics) notification(kind string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.notifications[notificationName(kind, err)]++
+}
+
+func (m *metrics) update(t time.Time, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.updates[result(err)]++
+	if err == nil {
+		m.lastUpdate = t
+	}
+}
+
+func result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+
+	return "success"
+}
+
+// writeTo writes every metric in the Prometheus text exposition format. Series are
+// sorted, so that the output is stable between scrapes.
+func (m *metrics) writeTo(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := bufio.NewWriter(w)
+
+	family(b, "nflpickem_http_requests_total", "counter", "HTTP requests answered, by route, method and status code")
+	requests := make([]requestLabels, 0, len(m.requests))
+	for l := range m.requests {
+		requests = append(requests, l)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+
+	for _, l := range requests {
+		sample(b, "nflpickem_http_requests_total", labels("route", l.route, "method", l.method, "code", strconv.Itoa(l.code)), float64(m.requests[l]))
+	}
+
+	family(b, "nflpickem_http_request_duration_seconds", "histogram", "Time taken to answer HTTP requests, by route and method")
+	durations := make([]durationLabels, 0, len(m.durations))
+	for l := range m.durations {
+		durations = append(durations, l)
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		a, b := durations[i], durations[j]
+		return a.route < b.route || (a.route == b.route && a.method < b.method)
+	})
+
+	for _, l := range durations {
+		h := m.durations[l]
+		for i, upper := range durationBuckets {
+			sample(b, "nflpickem_http_request_duration_seconds_bucket", labels("route", l.route, "method", l.method, "le", formatFloat(upper)), float64(h.buckets[i]))
+		}
+		sample(b, "nflpickem_http_request_duration_seconds_bucket", labels("route", l.route, "method", l.method, "le", "+Inf"), float64(h.count))
+		sample(b, "nflpickem_http_request_duration_seconds_sum", labels("route", l.route, "method", l.method), h.sum)
+		sample(b, "nflpickem_http_request_duration_seconds_count", labels("route", l.route, "method", l.method), float64(h.count))
+	}
+
+	family(b, "nflpickem_login_failures_total", "counter", "Failed logins, by reason")
+	for _, reason := range sortedNames(m.loginFailures) {
+		sample(b, "nflpickem_login_failures_total", labels("reason", reason), float64(m.loginFailures[reason]))
+	}
+
+	family(b, "nflpickem_pick_submissions_total", "counter", "Pick sets successfully submitted")
+	sample(b, "nflpickem_pick_submissions_total", "", float64(m.pickSubmissions))
+
+	family(b, "nflpickem_notifications_total", "counter", "Notifications sent, by type and result")
+	for _, l := range sortedNames(m.notifications) {
+		kind, result := splitNotification(l)
+		sample(b, "nflpickem_notifications_total", labels("type", kind, "result", result), float64(m.notifications[l]))
+	}
+
+	family(b, "nflpickem_autoupdate_runs_total", "counter", "Scheduled score imports, by result")
+	for _, r := range sortedNames(m.updates) {
+		sample(b, "nflpickem_autoupdate_runs_total", labels("result", r), float64(m.updates[r]))
+	}
+
+	family(b, "nflpickem_autoupdate_last_success_timestamp_seconds", "gauge", "Unix time of the last successful score import, or 0 if there hasn't been one")
+	var last float64
+	if !m.lastUpdate.IsZero() {
+		last = float64(m.lastUpdate.Unix())
+	}
+	sample(b, "nflpickem_autoupdate_last_success_timestamp_seconds", "", last)
+
+	if m.liveSubscribers != nil {
+		family(b, "nflpickem_live_subscribers", "gauge", "Clients following the live event stream")
+		sample(b, "nflpickem_live_subscribers", "", float64(m.liveSubscribers()))
+	}
+
+	return b.Flush()
+}
+
+// sortedNames returns the names counted by the map, in order.
+func sortedNames(m map[string]uint64) []string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Notifications are counted by their type and result, joined into a single name
+func notificationName(kind string, err error) string {
+	return kind + " " + result(err)
+}
+
+func splitNotification(name string) (kind string, result string) {
+	kind, result, _ = strings.Cut(name, " ")
+	return kind, result
+}
+
+func family(w io.Writer, name string, typ string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func sample(w io.Writer, name string, series string, value float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, series, formatFloat(value))
+}
+
+// labels formats pairs of label names and values, escaping the values as the exposition
+// format requires.
+func labels(pairs ...string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], escaper.Replace(pairs[i+1])))
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// statusRecorder remembers the status code written through it, along with the cause of
+// an internal error reported by WriteError. Unwrap lets an http.ResponseController reach
+// the underlying connection, and Flush keeps streaming responses working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	err    error
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// countingPicker counts the pick sets that are successfully submitted.
+type countingPicker struct {
+	pickManager
+	metrics *metrics
+}
+
+func (p countingPicker) MakePicks(picks nflpickem.PickSet) error {
+	err := p.pickManager.MakePicks(picks)
+	if err == nil {
+		p.metrics.pickSubmission()
+	}
+
+	return err
+}
+
+// serveMetrics writes the server's metrics for Prometheus to scrape.
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	err := s.metrics.writeTo(w)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// healthz reports whether the server is alive, which requires that its database can be
+// reached.
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	err := s.db.PingContext(r.Context())
+	if err != nil {
+		log.Println(err)
+		WriteJSONError(w, http.StatusServiceUnavailable, "database unavailable")
+		return
+	}
+
+	WriteJSONSuccess(w, "ok")
+}
+
+// readyz reports whether the server is ready to be sent requests, which requires that its
+// database answers queries against the tables the server expects, and not just that it
+// can be reached.
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	_, err := s.db.Version(s.time.Now())
+	if err != nil {
+		log.Println(err)
+		WriteJSONError(w, http.StatusServiceUnavailable, "database not ready")
+		return
+	}
+
+	WriteJSONSuccess(w, "ok")
+}
+
+// RecordUpdate records a scheduled import of scores, which failed if err isn't nil.
+func (s *Server) RecordUpdate(err error) {
+	s.metrics.update(s.time.Now(), err)
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsMethodLabel(t *testing.T) {
+	s := newTestServer(t, newStubService(), time.Now(), Options{})
+
+	for _, method := range []string{"GET", "FOO", "BAR"} {
+		serve(s, httptest.NewRequest(method, "/api/oidc", nil))
+	}
+
+	var b strings.Builder
+	err := s.metrics.writeTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := b.String()
+	for _, series := range []string{
+		`nflpickem_http_requests_total{route="/api/oidc",method="GET",code="200"} 1`,
+		`nflpickem_http_requests_total{route="/api/oidc",method="OTHER",code="200"} 2`,
+	} {
+		if !strings.Contains(out, series) {
+			t.Errorf("missing %s", series)
+		}
+	}
+
+	if strings.Contains(out, `method="FOO"`) || strings.Contains(out, `method="BAR"`) {
+		t.Error("non-standard methods reported by name")
+	}
+}
@@ -0,0 +1,613 @@
+package http
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// OIDCOptions configures login through an OpenID Connect identity provider. Login through
+// the provider is disabled if Issuer is empty.
+type OIDCOptions struct {
+	// Issuer is the provider's issuer URL, used to discover the rest of its configuration
+	Issuer string
+	// ClientID and ClientSecret identify the pool to the provider
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the URL of the pool's callback endpoint, as registered with the provider
+	RedirectURL string
+}
+
+// oidcCookieName holds the state of a login in progress at the identity provider.
+const oidcCookieName = "nflpickem-oidc"
+
+// oidcLoginLifetime limits how long a user may take to log in at the identity provider.
+const oidcLoginLifetime = 10 * time.Minute
+
+// oidcSecondFactorCookieName holds the user who has logged in at the identity provider,
+// but must still provide their second factor before a session is started.
+const oidcSecondFactorCookieName = "nflpickem-oidc-2fa"
+
+// oidcSecondFactorPage is where the user is sent to provide their second factor.
+const oidcSecondFactorPage = "/login.html?otp=oidc"
+
+// oidcClockSkew tolerates small differences between our clock and the provider's.
+const oidcClockSkew = time.Minute
+
+var (
+	errOIDCState      = errors.New("invalid or expired OIDC login state")
+	errOIDCIDToken    = errors.New("invalid OIDC ID token")
+	errOIDCEmail      = errors.New("OIDC provider did not supply a verified e-mail address")
+	errOIDCUnknownKey = errors.New("OIDC ID token signed by an unknown key")
+	errOIDCPending    = errors.New("no OIDC login is waiting for a second factor")
+)
+
+// oidcLogin is the state of a login in progress, kept in a secure cookie until the
+// provider redirects the user back to us.
+type oidcLogin struct {
+	State    string
+	Nonce    string
+	Verifier string
+	Expires  time.Time
+}
+
+// oidcSecondFactor is a login through the identity provider that is waiting for the user's
+// second factor, kept in a secure cookie until the user provides it.
+type oidcSecondFactor struct {
+	Email   string
+	Expires time.Time
+}
+
+// oidcDiscovery is the subset of the provider's discovery document that we use.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcClaims are the ID token claims that we use.
+type oidcClaims struct {
+	Issuer        string          `json:"iss"`
+	Audience      json.RawMessage `json:"aud"`
+	AuthorizedBy  string          `json:"azp"`
+	Expires       int64           `json:"exp"`
+	IssuedAt      int64           `json:"iat"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+}
+
+// oidcProvider talks to an OpenID Connect identity provider. Its configuration and keys
+// are fetched on first use, and the keys are refetched when a token is signed by a key
+// we haven't seen.
+type oidcProvider struct {
+	opts   OIDCOptions
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+func newOIDCProvider(opts OIDCOptions) *oidcProvider {
+	return &oidcProvider{
+		opts:   opts,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// configuration returns the provider's discovery document, fetching it if necessary.
+func (p *oidcProvider) configuration() (oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return *p.discovery, nil
+	}
+
+	var d oidcDiscovery
+	err := p.getJSON(strings.TrimSuffix(p.opts.Issuer, "/")+"/.well-known/openid-configuration", &d)
+	if err != nil {
+		return d, err
+	}
+
+	if d.Issuer != p.opts.Issuer {
+		return d, fmt.Errorf("OIDC provider reports issuer %s, expected %s", d.Issuer, p.opts.Issuer)
+	}
+
+	p.discovery = &d
+
+	return d, nil
+}
+
+// key returns the provider's public key with the given ID, refetching the provider's keys
+// if it isn't known.
+func (p *oidcProvider) key(kid string) (*rsa.PublicKey, error) {
+	d, err := p.configuration()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	err = p.getJSON(d.JWKSURI, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		p.keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	k, ok := p.keys[kid]
+	if !ok {
+		return nil, errOIDCUnknownKey
+	}
+
+	return k, nil
+}
+
+// authURL returns the URL at the provider where the user logs in.
+func (p *oidcProvider) authURL(login oidcLogin) (string, error) {
+	d, err := p.configuration()
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(login.Verifier))
+
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.opts.ClientID)
+	v.Set("redirect_uri", p.opts.RedirectURL)
+	v.Set("scope", "openid email profile")
+	v.Set("state", login.State)
+	v.Set("nonce", login.Nonce)
+	v.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return d.AuthorizationEndpoint + sep + v.Encode(), nil
+}
+
+// exchange trades the authorization code for the user's ID token, returning the token's
+// claims as verified at time t.
+func (p *oidcProvider) exchange(code string, login oidcLogin, t time.Time) (oidcClaims, error) {
+	d, err := p.configuration()
+	if err != nil {
+		return oidcClaims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.opts.RedirectURL)
+	form.Set("code_verifier", login.Verifier)
+
+	req, err := http.NewRequest("POST", d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oidcClaims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.opts.ClientID), url.QueryEscape(p.opts.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return oidcClaims{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return oidcClaims{}, fmt.Errorf("OIDC token endpoint returned %s", resp.Status)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tokens)
+	if err != nil {
+		return oidcClaims{}, err
+	}
+
+	return p.verify(tokens.IDToken, login.Nonce, t)
+}
+
+// verify checks the ID token's signature and claims at time t. Only RS256 signatures
+// are supported, which every OpenID Connect provider must offer.
+func (p *oidcProvider) verify(idToken string, nonce string, t time.Time) (oidcClaims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return oidcClaims{}, errOIDCIDToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodeJWTPart(parts[0], &header)
+	if err != nil || header.Alg != "RS256" {
+		return oidcClaims{}, errOIDCIDToken
+	}
+
+	key, err := p.key(header.Kid)
+	if err != nil {
+		return oidcClaims{}, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return oidcClaims{}, errOIDCIDToken
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	if err != nil {
+		return oidcClaims{}, errOIDCIDToken
+	}
+
+	var claims oidcClaims
+	err = decodeJWTPart(parts[1], &claims)
+	if err != nil {
+		return oidcClaims{}, errOIDCIDToken
+	}
+
+	if claims.Issuer != p.opts.Issuer || !claims.hasAudience(p.opts.ClientID) {
+		return oidcClaims{}, errOIDCIDToken
+	}
+
+	if t.After(time.Unix(claims.Expires, 0).Add(oidcClockSkew)) || time.Unix(claims.IssuedAt, 0).After(t.Add(oidcClockSkew)) {
+		return oidcClaims{}, errOIDCIDToken
+	}
+
+	if !hmac.Equal([]byte(claims.Nonce), []byte(nonce)) {
+		return oidcClaims{}, errOIDCIDToken
+	}
+
+	if claims.Email == "" || !claims.emailVerified() {
+		return oidcClaims{}, errOIDCEmail
+	}
+
+	return claims, nil
+}
+
+// hasAudience returns whether or not the token was issued to the given client. The
+// audience may be a single string or a list.
+func (c oidcClaims) hasAudience(clientID string) bool {
+	var single string
+	if json.Unmarshal(c.Audience, &single) == nil {
+		return single == clientID
+	}
+
+	var multiple []string
+	if json.Unmarshal(c.Audience, &multiple) != nil {
+		return false
+	}
+
+	for _, aud := range multiple {
+		if aud == clientID {
+			return len(multiple) == 1 || c.AuthorizedBy == clientID
+		}
+	}
+
+	return false
+}
+
+// emailVerified returns whether or not the provider has verified the e-mail claim. Some
+// providers send the claim as a string rather than a boolean.
+func (c oidcClaims) emailVerified() bool {
+	var b bool
+	if json.Unmarshal(c.EmailVerified, &b) == nil {
+		return b
+	}
+
+	var s string
+	if json.Unmarshal(c.EmailVerified, &s) == nil {
+		return s == "true"
+	}
+
+	return false
+}
+
+func (p *oidcProvider) getJSON(url string, v interface{}) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// randomString returns a random, URL safe, string.
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// loginProviderStatus reports whether or not users may log in through an identity provider.
+type loginProviderStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+// oidcEnabled reports whether or not users may log in through an identity provider.
+func (s *Server) oidcEnabled(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, loginProviderStatus{s.oidc != nil})
+}
+
+// oidcStart redirects the user to the identity provider to log in.
+func (s *Server) oidcStart(w http.ResponseWriter, r *http.Request) {
+	var login oidcLogin
+	var err error
+
+	for _, v := range []*string{&login.State, &login.Nonce, &login.Verifier} {
+		*v, err = randomString()
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+	login.Expires = s.time.Now().Add(oidcLoginLifetime)
+
+	authURL, err := s.oidc.authURL(login)
+	if err != nil {
+		log.Println(err)
+		WriteJSONError(w, http.StatusBadGateway, "unable to reach identity provider")
+		return
+	}
+
+	encoded, err := s.sc.Encode(oidcCookieName, login)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    encoded,
+		Path:     "/",
+		MaxAge:   int(oidcLoginLifetime / time.Second),
+		Secure:   s.opts.Session.SecureCookie,
+		HttpOnly: true,
+		// The provider redirects back to us, which is a cross-site navigation
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// oidcCallback completes a login at the identity provider, starting a session for the user
+// whose verified e-mail address the provider vouches for.
+//
+// Users are not created by logging in; the e-mail address must already belong to a user
+// of the pool.
+//
+// The provider only stands in for the user's password. A user who has enabled two-factor
+// authentication is sent to provide their second factor to oidcVerify instead, and no
+// session is started until they do.
+func (s *Server) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, errOIDCState.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   s.opts.Session.SecureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	var login oidcLogin
+	err = s.sc.Decode(oidcCookieName, cookie.Value, &login)
+	if err != nil || s.time.Now().After(login.Expires) || !hmac.Equal([]byte(r.FormValue("state")), []byte(login.State)) {
+		WriteJSONError(w, http.StatusBadRequest, errOIDCState.Error())
+		return
+	}
+
+	if e := r.FormValue("error"); e != "" {
+		WriteJSONError(w, http.StatusUnauthorized, fmt.Sprintf("identity provider refused login: %s", e))
+		return
+	}
+
+	claims, err := s.oidc.exchange(r.FormValue("code"), login, s.time.Now())
+	if err == errOIDCEmail {
+		WriteJSONError(w, http.StatusForbidden, err.Error())
+		return
+	} else if err != nil {
+		log.Println(err)
+		WriteJSONError(w, http.StatusUnauthorized, "unable to verify login with identity provider")
+		return
+	}
+
+	user, err := s.db.User(claims.Email)
+	if err == nflpickem.ErrUnknownUser {
+		WriteJSONError(w, http.StatusForbidden, fmt.Sprintf("%s is not a member of the pool", claims.Email))
+		return
+	} else if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if user.Disabled {
+		WriteError(w, nflpickem.ErrUserDisabled)
+		return
+	} else if user.Pending {
+		WriteError(w, nflpickem.ErrUserPending)
+		return
+	}
+
+	setRequestUser(r, user.Email)
+
+	o, err := s.db.TOTP(user.Email)
+	if err != nil && err != nflpickem.ErrTOTPNotEnrolled {
+		WriteError(w, err)
+		return
+	}
+
+	if o.Enabled {
+		encoded, err := s.sc.Encode(oidcSecondFactorCookieName, oidcSecondFactor{user.Email, s.time.Now().Add(oidcLoginLifetime)})
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcSecondFactorCookieName,
+			Value:    encoded,
+			Path:     "/",
+			MaxAge:   int(oidcLoginLifetime / time.Second),
+			Secure:   s.opts.Session.SecureCookie,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		http.Redirect(w, r, oidcSecondFactorPage, http.StatusFound)
+		return
+	}
+
+	err = s.startSession(w, user)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/picks.html", http.StatusFound)
+}
+
+// oidcVerify completes a login through the identity provider that is waiting for the
+// user's second factor, which is sent in the X-OTP header. Failures count towards the
+// same lockouts as logging in with a password.
+func (s *Server) oidcVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+		return
+	}
+
+	cookie, err := r.Cookie(oidcSecondFactorCookieName)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, errOIDCPending.Error())
+		return
+	}
+
+	var pending oidcSecondFactor
+	err = s.sc.Decode(oidcSecondFactorCookieName, cookie.Value, &pending)
+	if err != nil || s.time.Now().After(pending.Expires) {
+		WriteJSONError(w, http.StatusBadRequest, errOIDCPending.Error())
+		return
+	}
+
+	user, err := s.guardLogin(r, pending.Email, func() (nflpickem.User, error) {
+		user, err := s.db.User(pending.Email)
+		if err != nil {
+			return nflpickem.User{}, err
+		}
+
+		if user.Disabled {
+			return nflpickem.User{}, nflpickem.ErrUserDisabled
+		} else if user.Pending {
+			return nflpickem.User{}, nflpickem.ErrUserPending
+		}
+
+		return user, s.verifySecondFactor(r, user)
+	})
+	if lockout, ok := err.(errLockedOut); ok {
+		writeLockedOut(w, lockout, s.time.Now())
+		return
+	} else if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcSecondFactorCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   s.opts.Session.SecureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	setRequestUser(r, user.Email)
+
+	err = s.startSession(w, user)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteJSONSuccess(w, "successfully logged in")
+}
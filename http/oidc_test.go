@@ -0,0 +1,473 @@
+package http
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+const (
+	stubClientID     = "nflpickem"
+	stubClientSecret = "secret"
+	stubRedirectURL  = "https://pool.example.com/api/oidc/callback"
+	stubCode         = "stub-code"
+	stubKeyID        = "stub-key"
+)
+
+// oidcTestTime is the time on both the pool's and the provider's clocks.
+var oidcTestTime = time.Date(2017, time.September, 10, 13, 0, 10, 0, time.UTC)
+
+// stubProvider is an OpenID Connect identity provider that issues an ID token with the
+// claims chosen by the test for every authorization code it accepts.
+type stubProvider struct {
+	*httptest.Server
+	t *testing.T
+
+	key         *rsa.PrivateKey
+	signer      *rsa.PrivateKey
+	signerID    string
+	issuer      string
+	discoveries int
+
+	challenge string
+	claims    map[string]interface{}
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &stubProvider{t: t, key: key, signer: key, signerID: stubKeyID}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+
+	p.Server = httptest.NewServer(mux)
+	p.issuer = p.URL
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func (p *stubProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	p.discoveries++
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 p.issuer,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/jwks",
+	})
+}
+
+func (p *stubProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": stubKeyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+// token accepts the authorization code only from the pool, and only with the verifier
+// for the challenge sent when the login started.
+func (p *stubProvider) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if r.Method != "POST" || !ok || id != stubClientID || secret != stubClientSecret {
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+		return
+	}
+
+	verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("code") != stubCode ||
+		r.PostFormValue("redirect_uri") != stubRedirectURL || base64.RawURLEncoding.EncodeToString(verifier[:]) != p.challenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"id_token": p.sign(p.claims)})
+}
+
+// sign returns an RS256 signed JWT of the claims.
+func (p *stubProvider) sign(claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": p.signerID, "typ": "JWT"})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.signer, crypto.SHA256, digest[:])
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// options configures a server to log in through the provider.
+func (p *stubProvider) options() Options {
+	return Options{
+		OIDC: OIDCOptions{
+			Issuer:       p.URL,
+			ClientID:     stubClientID,
+			ClientSecret: stubClientSecret,
+			RedirectURL:  stubRedirectURL,
+		},
+	}
+}
+
+// validClaims are the claims of a token the pool should accept for the given user.
+func (p *stubProvider) validClaims(email string, nonce string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":            p.URL,
+		"sub":            "12345",
+		"aud":            stubClientID,
+		"exp":            oidcTestTime.Add(time.Hour).Unix(),
+		"iat":            oidcTestTime.Unix(),
+		"nonce":          nonce,
+		"email":          email,
+		"email_verified": true,
+	}
+}
+
+// login logs in to the server through the provider, which issues a token with the claims
+// returned by claims for the login's nonce. The response to the callback is returned.
+func (p *stubProvider) login(s *Server, claims func(nonce string) map[string]interface{}) *http.Response {
+	resp := serve(s, httptest.NewRequest("GET", "/api/oidc/login", nil))
+	if resp.StatusCode != http.StatusFound {
+		p.t.Fatalf("login returned %d, expected a redirect", resp.StatusCode)
+	}
+
+	auth, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	q := auth.Query()
+	p.challenge = q.Get("code_challenge")
+	p.claims = claims(q.Get("nonce"))
+
+	callback := httptest.NewRequest("GET", "/api/oidc/callback?code="+stubCode+"&state="+url.QueryEscape(q.Get("state")), nil)
+
+	return serve(s, callback, responseCookie(resp, oidcCookieName))
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	p := newStubProvider(t)
+	s := newTestServer(t, newStubService(), oidcTestTime, p.options())
+
+	for i := 0; i < 2; i++ {
+		resp := serve(s, httptest.NewRequest("GET", "/api/oidc/login", nil))
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("login returned %d, expected a redirect", resp.StatusCode)
+		}
+
+		auth, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(auth.String(), p.URL+"/authorize?") {
+			t.Errorf("redirected to %s, expected the discovered authorization endpoint", auth)
+		}
+
+		q := auth.Query()
+		expected := map[string]string{
+			"response_type":         "code",
+			"client_id":             stubClientID,
+			"redirect_uri":          stubRedirectURL,
+			"code_challenge_method": "S256",
+		}
+		for name, value := range expected {
+			if q.Get(name) != value {
+				t.Errorf("%s is %q, expected %q", name, q.Get(name), value)
+			}
+		}
+
+		for _, name := range []string{"state", "nonce", "code_challenge"} {
+			if q.Get(name) == "" {
+				t.Errorf("%s is missing", name)
+			}
+		}
+
+		if responseCookie(resp, oidcCookieName) == nil {
+			t.Error("login state cookie not set")
+		}
+	}
+
+	if p.discoveries != 1 {
+		t.Errorf("discovery document fetched %d times, expected once", p.discoveries)
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	p := newStubProvider(t)
+	p.issuer = "https://elsewhere.example.com"
+	s := newTestServer(t, newStubService(), oidcTestTime, p.options())
+
+	resp := serve(s, httptest.NewRequest("GET", "/api/oidc/login", nil))
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("login returned %d, expected %d", resp.StatusCode, http.StatusBadGateway)
+	}
+}
+
+func TestOIDCCallback(t *testing.T) {
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		claims   func(c map[string]interface{})
+		signer   *rsa.PrivateKey
+		signerID string
+		status   int
+		location string
+	}{
+		{name: "valid token", email: "alice@example.com", status: http.StatusFound, location: "/picks.html"},
+		{
+			name:     "email_verified as a string",
+			email:    "alice@example.com",
+			claims:   func(c map[string]interface{}) { c["email_verified"] = "true" },
+			status:   http.StatusFound,
+			location: "/picks.html",
+		},
+		{
+			name:     "audience list authorized for the pool",
+			email:    "alice@example.com",
+			claims:   func(c map[string]interface{}) { c["aud"] = []string{stubClientID, "other"}; c["azp"] = stubClientID },
+			status:   http.StatusFound,
+			location: "/picks.html",
+		},
+		{name: "signed by another key", email: "alice@example.com", signer: other, status: http.StatusUnauthorized},
+		{name: "signed by an unknown key", email: "alice@example.com", signer: other, signerID: "other-key", status: http.StatusUnauthorized},
+		{
+			name:   "wrong issuer",
+			email:  "alice@example.com",
+			claims: func(c map[string]interface{}) { c["iss"] = "https://elsewhere.example.com" },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "wrong audience",
+			email:  "alice@example.com",
+			claims: func(c map[string]interface{}) { c["aud"] = "other" },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "audience list not authorized for the pool",
+			email:  "alice@example.com",
+			claims: func(c map[string]interface{}) { c["aud"] = []string{stubClientID, "other"} },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "expired",
+			email:  "alice@example.com",
+			claims: func(c map[string]interface{}) { c["exp"] = oidcTestTime.Add(-time.Hour).Unix() },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "issued in the future",
+			email:  "alice@example.com",
+			claims: func(c map[string]interface{}) { c["iat"] = oidcTestTime.Add(time.Hour).Unix() },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "wrong nonce",
+			email:  "alice@example.com",
+			claims: func(c map[string]interface{}) { c["nonce"] = "replayed" },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "email not verified",
+			email:  "alice@example.com",
+			claims: func(c map[string]interface{}) { c["email_verified"] = false },
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "email_verified missing",
+			email:  "alice@example.com",
+			claims: func(c map[string]interface{}) { delete(c, "email_verified") },
+			status: http.StatusForbidden,
+		},
+		{name: "unknown user", email: "mallory@example.com", status: http.StatusForbidden},
+		{name: "disabled user", email: "dave@example.com", status: http.StatusForbidden},
+		{name: "pending user", email: "erin@example.com", status: http.StatusForbidden},
+	}
+
+	p := newStubProvider(t)
+	db := newStubService(
+		nflpickem.User{FirstName: "Alice", Email: "alice@example.com"},
+		nflpickem.User{FirstName: "Dave", Email: "dave@example.com", Disabled: true},
+		nflpickem.User{FirstName: "Erin", Email: "erin@example.com", Pending: true},
+	)
+	s := newTestServer(t, db, oidcTestTime, p.options())
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p.signer, p.signerID = p.key, stubKeyID
+			if test.signer != nil {
+				p.signer = test.signer
+			}
+			if test.signerID != "" {
+				p.signerID = test.signerID
+			}
+
+			resp := p.login(s, func(nonce string) map[string]interface{} {
+				c := p.validClaims(test.email, nonce)
+				if test.claims != nil {
+					test.claims(c)
+				}
+				return c
+			})
+
+			if resp.StatusCode != test.status {
+				t.Fatalf("callback returned %d, expected %d", resp.StatusCode, test.status)
+			}
+
+			if location := resp.Header.Get("Location"); location != test.location {
+				t.Errorf("redirected to %q, expected %q", location, test.location)
+			}
+
+			session := responseCookie(resp, sessionCookieName)
+			if started := session != nil && session.MaxAge > 0; started != (test.status == http.StatusFound) {
+				t.Errorf("session started: %t", started)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackState(t *testing.T) {
+	p := newStubProvider(t)
+	s := newTestServer(t, newStubService(nflpickem.User{Email: "alice@example.com"}), oidcTestTime, p.options())
+
+	resp := serve(s, httptest.NewRequest("GET", "/api/oidc/callback?code="+stubCode+"&state=guessed", nil))
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback without a login returned %d, expected %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	start := serve(s, httptest.NewRequest("GET", "/api/oidc/login", nil))
+	resp = serve(s, httptest.NewRequest("GET", "/api/oidc/callback?code="+stubCode+"&state=guessed", nil), responseCookie(start, oidcCookieName))
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback with the wrong state returned %d, expected %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	auth, err := url.Parse(start.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.time = fixedTime(oidcTestTime.Add(oidcLoginLifetime + time.Second))
+	resp = serve(s, httptest.NewRequest("GET", "/api/oidc/callback?code="+stubCode+"&state="+url.QueryEscape(auth.Query().Get("state")), nil), responseCookie(start, oidcCookieName))
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback after the login expired returned %d, expected %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestOIDCSecondFactor(t *testing.T) {
+	now := oidcTestTime
+	secret, err := nflpickem.NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := newStubProvider(t)
+	db := newStubService(nflpickem.User{FirstName: "Alice", Email: "alice@example.com"})
+	db.totp["alice@example.com"] = nflpickem.TOTP{Secret: secret, Enabled: true}
+	s := newTestServer(t, db, now, p.options())
+
+	resp := p.login(s, func(nonce string) map[string]interface{} {
+		return p.validClaims("alice@example.com", nonce)
+	})
+
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != oidcSecondFactorPage {
+		t.Fatalf("callback returned %d to %q, expected a redirect to %q", resp.StatusCode, resp.Header.Get("Location"), oidcSecondFactorPage)
+	}
+
+	if responseCookie(resp, sessionCookieName) != nil {
+		t.Fatal("session started before the second factor was provided")
+	}
+
+	pending := responseCookie(resp, oidcSecondFactorCookieName)
+	if pending == nil {
+		t.Fatal("pending login cookie not set")
+	}
+
+	verify := func(code string, cookies ...*http.Cookie) *http.Response {
+		r := httptest.NewRequest("POST", "/api/oidc/verify", nil)
+		if code != "" {
+			r.Header.Set(otpHeader, code)
+		}
+		return serve(s, r, cookies...)
+	}
+
+	code, err := nflpickem.TOTPCode(secret, nflpickem.TOTPStep(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := verify(code); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("verify without a pending login returned %d, expected %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	if resp := verify("", pending); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("verify without a code returned %d, expected %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	wrong, err := nflpickem.TOTPCode(secret, nflpickem.TOTPStep(now)+10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := verify(wrong, pending); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("verify with the wrong code returned %d, expected %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	if l := db.lockouts[nflpickem.AccountLockout]["alice@example.com"]; l.Failures != 1 {
+		t.Errorf("account has %d failures, expected the wrong code to count", l.Failures)
+	}
+
+	resp = verify(code, pending)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("verify returned %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if session := responseCookie(resp, sessionCookieName); session == nil || session.MaxAge <= 0 {
+		t.Error("session not started")
+	}
+
+	if c := responseCookie(resp, oidcSecondFactorCookieName); c == nil || c.MaxAge >= 0 {
+		t.Error("pending login cookie not cleared")
+	}
+
+	if resp := verify(code, pending); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("replayed code returned %d, expected %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,782 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// apiAuth is the authentication an endpoint requires.
+type apiAuth int
+
+const (
+	apiPublic apiAuth = iota
+	apiLogin
+	apiAdmin
+)
+
+// apiParam is a query string or form parameter accepted by an endpoint.
+type apiParam struct {
+	name        string
+	typ         string
+	required    bool
+	description string
+}
+
+// apiOperation describes one method of an endpoint for the OpenAPI document.
+//
+// Path parameters are taken from the route's pattern. A nil response means the endpoint
+// answers with a status message, unless it streams a response of the stream media type or
+// answers with text of the contentType media type. Bodies and responses are described by a
+// value of the type that is encoded, so that the document follows the Go types. Cached
+// operations answer conditional requests with 304 Not Modified.
+type apiOperation struct {
+	summary     string
+	auth        apiAuth
+	scope       nflpickem.TokenScope
+	query       []apiParam
+	form        []apiParam
+	body        interface{}
+	response    interface{}
+	redirect    bool
+	stream      string
+	contentType string
+	cached      bool
+}
+
+var (
+	yearParam = apiParam{"year", "integer", true, "Year of the season"}
+	weekParam = apiParam{"week", "integer", true, "Week of the season, starting at 1"}
+	weekQuery = []apiParam{yearParam, weekParam}
+	emailForm = []apiParam{{"email", "string", true, "E-mail address of the user"}}
+	codeForm  = []apiParam{{"code", "string", true, "Code from an authenticator app, or a recovery code"}}
+
+	picksUserParam = apiParam{"username", "string", false, "Deprecated. Must be the logged in user's e-mail address if given."}
+)
+
+// Summaries shared by the versions of an endpoint
+const (
+	dashboardSummary  = "The current week, and a week's games, the logged in user's picks, revealed results, and totals, as of the same moment"
+	liveEventsSummary = `Stream changes as Server-Sent Events: "score" and "status" events carry a GameEvent, and "totals" events carry a TotalsEvent`
+)
+
+// apiSpec describes every endpoint served by the API, keyed by its pattern relative to the
+// route prefix and then by method. Routes served outside of the prefix are keyed by their
+// full pattern. NewServer refuses to create a server with a route that isn't described here.
+var apiSpec = map[string]map[string]apiOperation{
+	"/metrics": {
+		"GET": {summary: "Server metrics, in the Prometheus text format", contentType: "text/plain"},
+	},
+	"/healthz": {
+		"GET": {summary: "Whether the server is alive and can reach its database"},
+	},
+	"/readyz": {
+		"GET": {summary: "Whether the server's database is ready to answer queries"},
+	},
+	"/openapi.json": {
+		"GET": {summary: "This OpenAPI document", response: map[string]interface{}{}},
+	},
+
+	"/login": {
+		"POST": {summary: "Log in with HTTP Basic Auth, starting a session. Accounts with two-factor authentication must send the X-OTP header."},
+	},
+	"/logout": {
+		"GET":  {summary: "End the current session"},
+		"POST": {summary: "End the current session"},
+	},
+	"/logout/all": {
+		"POST": {summary: "End every session of the logged in user", auth: apiLogin},
+	},
+	"/state": {
+		"GET": {summary: "The logged in user and the session's CSRF token", response: sessionState{}},
+	},
+	"/oidc": {
+		"GET": {summary: "Whether or not users may log in through an identity provider", response: loginProviderStatus{}},
+	},
+	"/oidc/login": {
+		"GET": {summary: "Redirect to the identity provider to log in", redirect: true},
+	},
+	"/oidc/callback": {
+		"GET": {
+			summary: "Complete a login through the identity provider, starting a session",
+			query: []apiParam{
+				{"code", "string", true, "Authorization code from the identity provider"},
+				{"state", "string", true, "State sent to the identity provider"},
+			},
+			redirect: true,
+		},
+	},
+	"/oidc/verify": {
+		"POST": {summary: "Complete a login through the identity provider that is waiting for the second factor sent in the X-OTP header, starting a session"},
+	},
+
+	"/current": {
+		"GET": {summary: "The current week of the season", response: nflpickem.Week{}},
+	},
+	"/games": {
+		"GET": {
+			summary:  "Games of a week",
+			query:    append(weekQuery, apiParam{"kind", "string", false, `"cumulative" returns every game of the season up to the week`}),
+			response: []nflpickem.Game{},
+			cached:   true,
+		},
+	},
+	"/teams/standings": {
+		"GET": {summary: "Team standings as of a week", query: weekQuery, response: []nflpickem.TeamStanding{}, cached: true},
+	},
+	"/results": {
+		"GET": {summary: "Every user's picks for the games of a week that have started", query: weekQuery, response: []nflpickem.Result{}, cached: true},
+	},
+	"/totals": {
+		"GET": {
+			summary:  `Every user's points for a week. With type "outlook", the response is an array of Outlook instead.`,
+			query:    append(weekQuery, apiParam{"type", "string", false, `"cumulative" or "outlook"`}),
+			response: []nflpickem.WeekTotal{},
+			cached:   true,
+		},
+	},
+	"/consensus": {
+		"GET": {summary: "How users picked the games of a week that have started", query: weekQuery, response: []nflpickem.Consensus{}, cached: true},
+	},
+	"/dashboard": {
+		"GET": {
+			summary: dashboardSummary,
+			auth:    apiLogin,
+			query: []apiParam{
+				{"year", "integer", false, "Year of the season, defaulting to the current week"},
+				{"week", "integer", false, "Week of the season, required with year"},
+			},
+			response: nflpickem.Dashboard{},
+		},
+	},
+	"/events": {
+		"GET": {
+			summary: liveEventsSummary,
+			query: []apiParam{
+				{"year", "integer", false, "Only send events for the year"},
+				{"week", "integer", false, "Only send events for the week"},
+			},
+			stream: "text/event-stream",
+		},
+	},
+	"/simulate": {
+		"GET": {
+			summary: "Every user's probability of winning a week",
+			query: append(weekQuery,
+				apiParam{"model", "string", false, `"coin" or "record"`},
+				apiParam{"trials", "integer", false, "Number of simulated weeks"},
+				apiParam{"seed", "integer", false, "Seed that makes the simulation repeatable"},
+			),
+			response: []nflpickem.WinProbability{},
+		},
+	},
+
+	"/picks": {
+		"GET": {
+			summary:  "The logged in user's picks for a week",
+			auth:     apiLogin,
+			query:    append(weekQuery, picksUserParam),
+			response: nflpickem.PickSet{},
+		},
+		"POST": {
+			summary:  "Make the logged in user's picks for a week. Picks for games that have started are ignored.",
+			auth:     apiLogin,
+			scope:    nflpickem.ScopePicksWrite,
+			query:    append(weekQuery, picksUserParam),
+			body:     nflpickem.PickSet{},
+			response: nflpickem.PickSet{},
+		},
+	},
+	"/password": {
+		"POST": {
+			summary: "Change the logged in user's password",
+			auth:    apiLogin,
+			form: []apiParam{
+				{"oldPassword", "string", true, ""},
+				{"newPassword", "string", true, ""},
+			},
+		},
+	},
+	"/password/forgot": {
+		"POST": {
+			summary: "E-mail a password reset link",
+			form:    []apiParam{{"username", "string", true, "E-mail address of the user"}},
+		},
+	},
+	"/password/reset": {
+		"POST": {
+			summary: "Reset a password with the token from a reset link",
+			form: []apiParam{
+				{"token", "string", true, ""},
+				{"newPassword", "string", true, ""},
+			},
+		},
+	},
+	"/tokens": {
+		"GET": {summary: "The logged in user's API tokens", auth: apiLogin, response: []nflpickem.APIToken{}},
+		"POST": {
+			summary: "Create an API token. The token is only returned in this response.",
+			auth:    apiLogin,
+			form: []apiParam{
+				{"name", "string", true, ""},
+				{"scope", "string", true, `"read-only" or "picks:write"`},
+			},
+			response: createdAPIToken{},
+		},
+	},
+	"/tokens/revoke": {
+		"POST": {
+			summary: "Revoke one of the logged in user's API tokens",
+			auth:    apiLogin,
+			form:    []apiParam{{"id", "integer", true, ""}},
+		},
+	},
+	"/2fa": {
+		"GET": {summary: "Whether or not two-factor authentication is enabled", auth: apiLogin, response: nflpickem.TOTP{}},
+	},
+	"/2fa/enroll": {
+		"POST": {summary: "Generate a new TOTP secret", auth: apiLogin, response: twoFactorEnrollment{}},
+	},
+	"/2fa/enable": {
+		"POST": {
+			summary:  "Confirm enrollment with a TOTP code. The recovery codes are only returned in this response.",
+			auth:     apiLogin,
+			form:     []apiParam{{"code", "string", true, "Code from an authenticator app"}},
+			response: recoveryCodes{},
+		},
+	},
+	"/2fa/disable": {
+		"POST": {summary: "Disable two-factor authentication", auth: apiLogin, form: codeForm},
+	},
+	"/profile": {
+		"GET":  {summary: "The logged in user's profile", auth: apiLogin, response: nflpickem.Profile{}},
+		"POST": {summary: "Update the logged in user's profile. The e-mail address is ignored.", auth: apiLogin, body: nflpickem.Profile{}},
+		"PUT":  {summary: "Update the logged in user's profile. The e-mail address is ignored.", auth: apiLogin, body: nflpickem.Profile{}},
+	},
+	"/profile/email": {
+		"POST": {summary: "E-mail a verification link to a new address", auth: apiLogin, form: []apiParam{{"email", "string", true, "New e-mail address"}}},
+	},
+	"/profile/email/verify": {
+		"POST": {summary: "Change the e-mail address with the token from a verification link", form: []apiParam{{"token", "string", true, ""}}},
+	},
+	"/register": {
+		"POST": {
+			summary: "Register with an invite code",
+			form: []apiParam{
+				{"code", "string", true, "Invite code"},
+				{"firstName", "string", true, ""},
+				{"lastName", "string", true, ""},
+				{"email", "string", true, ""},
+				{"password", "string", true, ""},
+			},
+		},
+	},
+
+	"/admin/users": {
+		"GET": {summary: "Every user", auth: apiAdmin, response: []nflpickem.User{}},
+		"POST": {
+			summary: "Add a user",
+			auth:    apiAdmin,
+			form: []apiParam{
+				{"firstName", "string", true, ""},
+				{"lastName", "string", true, ""},
+				{"email", "string", true, ""},
+				{"password", "string", true, ""},
+				{"admin", "boolean", false, ""},
+			},
+		},
+	},
+	"/admin/users/disable": {
+		"POST": {summary: "Disable or enable a user", auth: apiAdmin, form: append(emailForm, apiParam{"disabled", "boolean", false, "Defaults to true"})},
+	},
+	"/admin/users/promote": {
+		"POST": {summary: "Grant or revoke admin", auth: apiAdmin, form: append(emailForm, apiParam{"admin", "boolean", false, "Defaults to true"})},
+	},
+	"/admin/users/password": {
+		"POST": {summary: "Set a user's password", auth: apiAdmin, form: append(emailForm, apiParam{"password", "string", true, ""})},
+	},
+	"/admin/users/pending": {
+		"GET": {summary: "Users awaiting approval", auth: apiAdmin, response: []nflpickem.User{}},
+	},
+	"/admin/users/approve": {
+		"POST": {summary: "Approve a pending user", auth: apiAdmin, form: emailForm},
+	},
+	"/admin/users/reject": {
+		"POST": {summary: "Reject a pending user", auth: apiAdmin, form: emailForm},
+	},
+	"/admin/invites": {
+		"GET": {summary: "Every invite", auth: apiAdmin, response: []nflpickem.Invite{}},
+		"POST": {
+			summary: "Create an invite. The code is only returned in this response.",
+			auth:    apiAdmin,
+			form: []apiParam{
+				{"expires", "string", false, `Duration until the invite expires, such as "72h"`},
+				{"uses", "integer", false, "Number of users that can register with the invite"},
+			},
+			response: createdInvite{},
+		},
+	},
+	"/admin/invites/revoke": {
+		"POST": {summary: "Revoke an invite", auth: apiAdmin, form: []apiParam{{"id", "integer", true, ""}}},
+	},
+	"/admin/lockouts": {
+		"GET": {summary: "Accounts and addresses with failed logins", auth: apiAdmin, response: []nflpickem.Lockout{}},
+	},
+	"/admin/games/score": {
+		"POST": {
+			summary: "Record the score of a game, pushing the change to live clients",
+			auth:    apiAdmin,
+			form: append(weekQuery,
+				apiParam{"home", "string", true, "Nickname of the home team"},
+				apiParam{"homeScore", "integer", true, ""},
+				apiParam{"awayScore", "integer", true, ""},
+			),
+		},
+	},
+	"/admin/lockouts/clear": {
+		"POST": {
+			summary: "Clear a lockout",
+			auth:    apiAdmin,
+			form: []apiParam{
+				{"kind", "string", true, `"account" or "ip"`},
+				{"key", "string", true, "Username or IP address"},
+			},
+		},
+	},
+
+	"/years": {
+		"GET": {summary: "Every season", response: []int{}},
+	},
+	"/history": {
+		"GET": {summary: "Every user's history across seasons", response: []nflpickem.History{}},
+	},
+	"/records": {
+		"GET": {summary: "Records across seasons", response: nflpickem.Records{}},
+	},
+
+	"/v2/seasons": {
+		"GET": {summary: "Every season", response: []int{}},
+	},
+	"/v2/seasons/current": {
+		"GET": {summary: "The current week of the season", response: nflpickem.Week{}},
+	},
+	"/v2/seasons/{year}/weeks/{week}/games": {
+		"GET": {
+			summary:  "Games of a week",
+			query:    []apiParam{{"kind", "string", false, `"cumulative" returns every game of the season up to the week`}},
+			response: []nflpickem.Game{},
+			cached:   true,
+		},
+	},
+	"/v2/seasons/{year}/weeks/{week}/results": {
+		"GET": {summary: "Every user's picks for the games of a week that have started", response: []nflpickem.Result{}, cached: true},
+	},
+	"/v2/seasons/{year}/weeks/{week}/totals": {
+		"GET": {
+			summary:  `Every user's points for a week. With type "outlook", the response is an array of Outlook instead.`,
+			query:    []apiParam{{"type", "string", false, `"cumulative" or "outlook"`}},
+			response: []nflpickem.WeekTotal{},
+			cached:   true,
+		},
+	},
+	"/v2/seasons/{year}/weeks/{week}/consensus": {
+		"GET": {summary: "How users picked the games of a week that have started", response: []nflpickem.Consensus{}, cached: true},
+	},
+	"/v2/seasons/{year}/weeks/{week}/simulation": {
+		"GET": {
+			summary: "Every user's probability of winning a week",
+			query: []apiParam{
+				{"model", "string", false, `"coin" or "record"`},
+				{"trials", "integer", false, "Number of simulated weeks"},
+				{"seed", "integer", false, "Seed that makes the simulation repeatable"},
+			},
+			response: []nflpickem.WinProbability{},
+		},
+	},
+	"/v2/seasons/{year}/weeks/{week}/teams/standings": {
+		"GET": {summary: "Team standings as of a week", response: []nflpickem.TeamStanding{}, cached: true},
+	},
+	"/v2/dashboard": {
+		"GET": {summary: dashboardSummary + ", for the current week", auth: apiLogin, response: nflpickem.Dashboard{}},
+	},
+	"/v2/seasons/{year}/weeks/{week}/dashboard": {
+		"GET": {summary: dashboardSummary, auth: apiLogin, response: nflpickem.Dashboard{}},
+	},
+	"/v2/seasons/{year}/weeks/{week}/events": {
+		"GET": {summary: liveEventsSummary, stream: "text/event-stream"},
+	},
+	"/v2/seasons/{year}/weeks/{week}/picks": {
+		"GET": {summary: "The logged in user's picks for a week", auth: apiLogin, response: nflpickem.PickSet{}},
+		"POST": {
+			summary:  "Make the logged in user's picks for a week. Picks for games that have started are ignored.",
+			auth:     apiLogin,
+			scope:    nflpickem.ScopePicksWrite,
+			body:     nflpickem.PickSet{},
+			response: nflpickem.PickSet{},
+		},
+	},
+	"/v2/history": {
+		"GET": {summary: "Every user's history across seasons", response: []nflpickem.History{}},
+	},
+	"/v2/records": {
+		"GET": {summary: "Records across seasons", response: nflpickem.Records{}},
+	},
+}
+
+// newOpenAPIDocument builds the OpenAPI 3 document for the given routes, which were
+// registered under routePrefix. It fails if any route isn't described by apiSpec.
+func newOpenAPIDocument(routePrefix string, routes []route) (map[string]interface{}, error) {
+	prefix := strings.Join(splitPath(routePrefix), "/")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+
+	schemas := newSchemaGenerator()
+	paths := make(map[string]interface{})
+
+	for _, rt := range routes {
+		pattern := "/" + strings.Join(rt.segments, "/")
+		ops, ok := apiSpec[strings.TrimPrefix(pattern, prefix)]
+		if !ok || len(ops) == 0 {
+			return nil, fmt.Errorf("route %s has no OpenAPI description", pattern)
+		}
+
+		if _, ok := ops[rt.method]; rt.method != "" && !ok {
+			return nil, fmt.Errorf("route %s %s has no OpenAPI description", rt.method, pattern)
+		}
+
+		item, ok := paths[pattern].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[pattern] = item
+		}
+
+		for method, op := range ops {
+			if rt.method != "" && rt.method != method {
+				continue
+			}
+
+			item[strings.ToLower(method)] = op.document(rt.segments, schemas)
+		}
+	}
+
+	// Every failure is reported the same way, and live events are described by their data
+	for _, v := range []interface{}{statusResponse{}, gameEvent{}, totalsEvent{}} {
+		schemas.schema(reflect.TypeOf(v))
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "NFL Pick-Em Pool",
+			"version": "2",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]interface{}{
+				"basicAuth": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "basic",
+					"description": "Username and password, which starts a session. Accounts with two-factor authentication must also send a code in the X-OTP header.",
+				},
+				"bearerAuth": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "API token created through /tokens. Tokens may read from any endpoint, but only change state when their scope allows it.",
+				},
+				"sessionCookie": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "cookie",
+					"name":        sessionCookieName,
+					"description": fmt.Sprintf("Session started by logging in. Requests that change state must also send the session's CSRF token in the %s header.", csrfHeader),
+				},
+			},
+			"responses": map[string]interface{}{
+				"Error":           errorResponse("The request failed"),
+				"Unauthorized":    errorResponse("Login required, or the credentials were rejected"),
+				"Forbidden":       errorResponse("The user, API token, or CSRF token isn't allowed to make the request"),
+				"TooManyRequests": lockedOutResponse(),
+			},
+		},
+	}
+
+	return doc, nil
+}
+
+// document returns the OpenAPI operation object for op, served at a route with the given
+// path segments.
+func (op apiOperation) document(segments []string, schemas *schemaGenerator) map[string]interface{} {
+	params := make([]interface{}, 0)
+	for _, s := range segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			params = append(params, map[string]interface{}{
+				"name":     s[1 : len(s)-1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "integer"},
+			})
+		}
+	}
+
+	for _, p := range op.query {
+		params = append(params, map[string]interface{}{
+			"name":        p.name,
+			"in":          "query",
+			"required":    p.required,
+			"description": p.description,
+			"schema":      map[string]interface{}{"type": p.typ},
+		})
+	}
+
+	responses := map[string]interface{}{
+		"default": map[string]interface{}{"$ref": "#/components/responses/Error"},
+	}
+
+	if op.cached {
+		for _, h := range []string{"If-None-Match", "If-Modified-Since"} {
+			params = append(params, map[string]interface{}{
+				"name":   h,
+				"in":     "header",
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+
+		responses["304"] = map[string]interface{}{"description": "Not Modified"}
+	}
+
+	if op.redirect {
+		responses["302"] = map[string]interface{}{"description": "Redirect"}
+	} else if op.stream != "" || op.contentType != "" {
+		mediaType, description := op.contentType, "Success"
+		if op.stream != "" {
+			mediaType, description = op.stream, "Stream of events"
+		}
+
+		responses["200"] = map[string]interface{}{
+			"description": description,
+			"content": map[string]interface{}{
+				mediaType: map[string]interface{}{
+					"schema": map[string]interface{}{"type": "string"},
+				},
+			},
+		}
+	} else {
+		response := op.response
+		if response == nil {
+			response = statusResponse{}
+		}
+
+		responses["200"] = map[string]interface{}{
+			"description": "Success",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": schemas.schema(reflect.TypeOf(response)),
+				},
+			},
+		}
+	}
+
+	doc := map[string]interface{}{
+		"summary":    op.summary,
+		"parameters": params,
+		"responses":  responses,
+	}
+
+	if op.body != nil {
+		doc["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": schemas.schema(reflect.TypeOf(op.body)),
+				},
+			},
+		}
+	} else if len(op.form) > 0 {
+		properties := make(map[string]interface{})
+		required := make([]string, 0)
+		for _, p := range op.form {
+			properties[p.name] = map[string]interface{}{"type": p.typ, "description": p.description}
+			if p.required {
+				required = append(required, p.name)
+			}
+		}
+
+		form := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			form["required"] = required
+		}
+
+		doc["requestBody"] = map[string]interface{}{
+			"required": len(required) > 0,
+			"content": map[string]interface{}{
+				"application/x-www-form-urlencoded": map[string]interface{}{"schema": form},
+			},
+		}
+	}
+
+	if op.auth == apiPublic {
+		doc["security"] = []interface{}{}
+		return doc
+	}
+
+	doc["security"] = []interface{}{
+		map[string]interface{}{"basicAuth": []string{}},
+		map[string]interface{}{"bearerAuth": []string{}},
+		map[string]interface{}{"sessionCookie": []string{}},
+	}
+
+	responses["401"] = map[string]interface{}{"$ref": "#/components/responses/Unauthorized"}
+	responses["403"] = map[string]interface{}{"$ref": "#/components/responses/Forbidden"}
+	responses["429"] = map[string]interface{}{"$ref": "#/components/responses/TooManyRequests"}
+
+	if op.scope != "" {
+		doc["description"] = fmt.Sprintf("API tokens must have the %s scope.", op.scope)
+	} else if op.auth == apiAdmin {
+		doc["description"] = "Only administrators may use this endpoint."
+	}
+
+	return doc
+}
+
+func errorResponse(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{"$ref": "#/components/schemas/StatusResponse"},
+			},
+		},
+	}
+}
+
+func lockedOutResponse() map[string]interface{} {
+	r := errorResponse("Too many failed logins for the account or address")
+	r["headers"] = map[string]interface{}{
+		"Retry-After": map[string]interface{}{
+			"description": "Seconds until the lockout ends",
+			"schema":      map[string]interface{}{"type": "integer"},
+		},
+	}
+
+	return r
+}
+
+// schemaGenerator derives JSON schemas from the Go types that are encoded as JSON. Named
+// struct types become components, which are referred to by the schemas that use them.
+type schemaGenerator struct {
+	components map[string]interface{}
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{components: make(map[string]interface{})}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the JSON schema of values of type t, as encoded by encoding/json.
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		return map[string]interface{}{"allOf": []interface{}{g.schema(t.Elem())}, "nullable": true}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := g.components[name]; !ok {
+			// Reserve the name first, in case the type refers to itself
+			g.components[name] = nil
+			g.components[name] = g.object(t)
+		}
+
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.object(t)
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// object returns the JSON schema of a struct type, whose properties are its encoded fields.
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	optional := make(map[string]bool)
+	g.fields(t, properties, optional)
+
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		if !optional[name] {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// fields adds the schema of each encoded field of t to properties, noting the fields that
+// are left out when empty in optional. The fields of embedded structs are promoted, as
+// they are by encoding/json.
+func (g *schemaGenerator) fields(t reflect.Type, properties map[string]interface{}, optional map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, properties, optional)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		properties[name] = g.schema(f.Type)
+
+		for _, option := range tag[1:] {
+			if option == "omitempty" {
+				optional[name] = true
+			}
+		}
+	}
+}
+
+// openAPI serves the OpenAPI document describing the API.
+func (s *Server) openAPI(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, s.apiDoc)
+}
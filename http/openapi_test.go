@@ -0,0 +1,56 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAPISpecCoversRoutes checks that apiSpec describes exactly the routes the server
+// registers, with login through an identity provider enabled so that its routes are too.
+func TestAPISpecCoversRoutes(t *testing.T) {
+	s := newTestServer(t, newStubService(), time.Now(), Options{
+		OIDC: OIDCOptions{
+			Issuer:       "https://idp.example.com",
+			ClientID:     stubClientID,
+			ClientSecret: stubClientSecret,
+			RedirectURL:  stubRedirectURL,
+		},
+	})
+
+	// The methods each described path is served with, where "" serves any method
+	served := make(map[string]map[string]bool)
+
+	for _, rt := range s.router.routes {
+		path := strings.TrimPrefix("/"+strings.Join(rt.segments, "/"), "/api")
+
+		ops, ok := apiSpec[path]
+		if !ok {
+			t.Errorf("route %s has no OpenAPI description", path)
+			continue
+		}
+
+		if _, ok := ops[rt.method]; rt.method != "" && !ok {
+			t.Errorf("route %s %s has no OpenAPI description", rt.method, path)
+		}
+
+		if served[path] == nil {
+			served[path] = make(map[string]bool)
+		}
+		served[path][rt.method] = true
+	}
+
+	for path, ops := range apiSpec {
+		methods, ok := served[path]
+		if !ok {
+			t.Errorf("%s is described, but no route serves it", path)
+			continue
+		}
+
+		for method := range ops {
+			if !methods[method] && !methods[""] {
+				t.Errorf("%s %s is described, but no route serves it", method, path)
+			}
+		}
+	}
+}
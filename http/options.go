@@ -0,0 +1,84 @@
+package http
+
+import (
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// Options configures the optional behavior of a Server.
+type Options struct {
+	Session SessionOptions
+
+	// AccountLockout throttles failed logins for a single username
+	AccountLockout nflpickem.LockoutPolicy
+	// AddressLockout throttles failed logins from a single IP address
+	AddressLockout nflpickem.LockoutPolicy
+	// TrustProxy identifies clients by the X-Forwarded-For header set by a reverse proxy
+	// instead of the address of the connection
+	TrustProxy bool
+	// RequireApproval holds users who register with an invite until an admin approves them
+	RequireApproval bool
+
+	// OIDC configures login through an OpenID Connect identity provider
+	OIDC OIDCOptions
+
+	// Assets are the web client's files, served from the URL path prefix each file system
+	// is keyed by. Requests for them are answered before the API routes.
+	Assets map[string]fs.FS
+	// TLS configures serving HTTPS instead of plain HTTP
+	TLS TLSOptions
+	// Timeouts limits how long connections may take, and how long shutdown may wait
+	Timeouts TimeoutOptions
+	// Logger logs every request once it has been answered. The default logger is used if
+	// it is nil.
+	Logger *slog.Logger
+}
+
+// TimeoutOptions limits how long the server waits on clients. A zero timeout never expires.
+//
+// Live event streams are exempt from the read and write timeouts, since they stay open for
+// as long as the client is connected.
+type TimeoutOptions struct {
+	// ReadHeader limits reading a request's headers
+	ReadHeader time.Duration
+	// Read limits reading an entire request, including its body
+	Read time.Duration
+	// Write limits writing a response, from the end of reading its request
+	Write time.Duration
+	// Idle limits how long a keep-alive connection waits for its next request
+	Idle time.Duration
+	// Shutdown limits how long a graceful shutdown waits for requests in progress and
+	// pending notifications before giving up on them
+	Shutdown time.Duration
+}
+
+// DefaultTimeoutOptions are generous enough for slow mobile clients, while still freeing
+// connections from clients that stop responding.
+var DefaultTimeoutOptions = TimeoutOptions{
+	ReadHeader: 10 * time.Second,
+	Read:       30 * time.Second,
+	Write:      60 * time.Second,
+	Idle:       2 * time.Minute,
+	Shutdown:   30 * time.Second,
+}
+
+// DefaultOptions are the options used by the NFL Pickem Server unless configured otherwise.
+var DefaultOptions = Options{
+	Session:  DefaultSessionOptions,
+	Timeouts: DefaultTimeoutOptions,
+	AccountLockout: nflpickem.LockoutPolicy{
+		Threshold: 5,
+		Base:      time.Minute,
+		Max:       time.Hour,
+		Reset:     24 * time.Hour,
+	},
+	AddressLockout: nflpickem.LockoutPolicy{
+		Threshold: 20,
+		Base:      time.Minute,
+		Max:       time.Hour,
+		Reset:     24 * time.Hour,
+	},
+}
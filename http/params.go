@@ -0,0 +1,50 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// intParam parses the named integer parameter from the request's path if the route has
+// it, or else from its query string or form.
+func intParam(r *http.Request, name string) (int, error) {
+	source := "path"
+	value, ok := pathParam(r, name)
+	if !ok {
+		source = "query"
+		value = r.FormValue(name)
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s %s parameter must be integer", name, source)
+	}
+
+	return i, nil
+}
+
+// weekParams parses and validates the year and week that the request is for.
+func weekParams(r *http.Request) (year int, week int, err error) {
+	year, err = intParam(r, "year")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if year <= 0 {
+		return 0, 0, fmt.Errorf("year must be positive")
+	}
+
+	week, err = intParam(r, "week")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if week < 1 || week > nflpickem.SeasonLength {
+		return 0, 0, fmt.Errorf("week must be between 1 and %d", nflpickem.SeasonLength)
+	}
+
+	return year, week, nil
+}
@@ -4,19 +4,28 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/ameske/nfl-pickem"
 )
 
+// passwordResetLifetime is how long an e-mailed password reset token may be redeemed.
+const passwordResetLifetime = time.Hour
+
 // ChangePassword processes the password change form, informing the user of any problems or success.
 func changePassword(db nflpickem.PasswordUpdater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
 		user, err := retrieveUser(r.Context())
 		if err == errNoUser {
 			WriteJSONError(w, http.StatusUnauthorized, "login required")
 			return
 		} else if err != nil {
-			WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			WriteError(w, err)
 			return
 		}
 
@@ -25,11 +34,75 @@ func changePassword(db nflpickem.PasswordUpdater) http.HandlerFunc {
 
 		err = db.UpdatePassword(user.Email, p, pN)
 		if err != nil {
-			log.Println(err)
-			WriteJSONError(w, http.StatusInternalServerError, "contact admin")
+			WriteError(w, err)
 			return
 		}
 
 		WriteJSONSuccess(w, fmt.Sprintf("Succesfully changed password for user %s", user.Email))
 	}
 }
+
+// forgotPassword e-mails a single-use password reset token to the given user.
+//
+// The response is the same whether or not the user exists, so that the endpoint
+// can't be used to discover usernames.
+//
+// Form Values:
+//	username: Required
+func forgotPassword(db nflpickem.PasswordResetter, notifier nflpickem.Notifier, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		username := r.FormValue("username")
+		if username == "" {
+			WriteJSONError(w, http.StatusBadRequest, "username is required")
+			return
+		}
+
+		expires := t.Now().Add(passwordResetLifetime)
+
+		token, err := db.CreatePasswordReset(username, expires)
+		if err == nil {
+			err = notifier.NotifyPasswordReset(username, token, expires)
+			if err != nil {
+				log.Printf("unable to send password reset: %v", err)
+			}
+		} else if err != nflpickem.ErrUnknownUser {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, "If the account exists, a password reset e-mail has been sent")
+	}
+}
+
+// resetPassword sets a new password using a token sent by forgotPassword.
+//
+// Form Values:
+//	token: Required
+//	newPassword: Required
+func resetPassword(db nflpickem.PasswordResetter, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		token, pN := r.FormValue("token"), r.FormValue("newPassword")
+		if token == "" || pN == "" {
+			WriteJSONError(w, http.StatusBadRequest, "token and newPassword are required")
+			return
+		}
+
+		err := db.RedeemPasswordReset(token, t.Now(), pN)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, "Successfully reset password")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/ameske/nfl-pickem"
 )
@@ -15,12 +14,13 @@ type pickManager interface {
 	nflpickem.Picker
 }
 
-// picks retrieves a user's picks for the provided week of the NFL season, OR updates
-// the picks based on provided picks in the request body.
+// picks retrieves the logged in user's picks for the provided week of the NFL season, OR
+// updates them based on provided picks in the request body.
 //
 // URL Parameters:
 //	year: Specifies the current year, Required
 //	week: Specifies the current week, Required
+//	username: must be the logged in user if given, Optional
 func picks(db pickManager, notifier nflpickem.Notifier, t TimeSource) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		user, err := retrieveUser(r.Context())
@@ -28,41 +28,63 @@ func picks(db pickManager, notifier nflpickem.Notifier, t TimeSource) http.Handl
 			WriteJSONError(w, http.StatusUnauthorized, "login required")
 			return
 		} else if err != nil {
-			WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			WriteError(w, err)
+			return
+		}
+
+		if username := r.URL.Query().Get("username"); username != "" && username != user.Email {
+			WriteJSONError(w, http.StatusForbidden, "picks may only be read or made by their user")
 			return
 		}
 
 		if r.Method == "GET" {
-			getPicks(user, db, w, r)
+			getPicks(user.Email, db, w, r)
 		} else if r.Method == "POST" {
-			postPicks(user, db, notifier, t, w, r)
+			postPicks(user.Email, db, notifier, t, w, r)
 		} else {
 			WriteJSONError(w, http.StatusMethodNotAllowed, "only GET or POST allowed")
 		}
 	}
 }
 
-// GetPicks returns the set of picks for the given user, year, and week.
-func getPicks(user nflpickem.User, db nflpickem.PickRetriever, w http.ResponseWriter, r *http.Request) {
-	yearStr := r.FormValue("year")
-	year, err := strconv.Atoi(yearStr)
-	if err != nil {
-		WriteJSONError(w, http.StatusBadRequest, "year query parameter must be integer")
-		return
+// userPicks retrieves the logged in user's picks for the week of the NFL season in the path.
+func userPicks(db pickManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		getPicks(user.Email, db, w, r)
 	}
+}
+
+// makePicks updates the logged in user's picks for the week of the NFL season in the path,
+// based on the picks in the request body.
+func makePicks(db pickManager, notifier nflpickem.Notifier, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
 
-	weekStr := r.FormValue("week")
-	week, err := strconv.Atoi(weekStr)
+		postPicks(user.Email, db, notifier, t, w, r)
+	}
+}
+
+// GetPicks returns the set of picks for the given user, year, and week.
+func getPicks(username string, db nflpickem.PickRetriever, w http.ResponseWriter, r *http.Request) {
+	year, week, err := weekParams(r)
 	if err != nil {
-		WriteJSONError(w, http.StatusBadRequest, "week query parameter must be integer")
+		WriteJSONError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	username := r.FormValue("username")
-
 	picks, err := db.UserPicks(username, year, week)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 
@@ -74,41 +96,26 @@ func getPicks(user nflpickem.User, db nflpickem.PickRetriever, w http.ResponseWr
 // In the event duplicate picks for the same game are made,
 // the last pick is always the pick that is stored.
 //
-// This endpoint restricts the set of picks to be for a pre-declared user,
-// declared in the URL.
+// Only the given user's picks are updated, so callers must pass the logged in user.
 //
 // If a selection is made for a locked game, it will be ignored.
-func postPicks(user nflpickem.User, db pickManager, notifier nflpickem.Notifier, t TimeSource, w http.ResponseWriter, r *http.Request) {
-	yearStr := r.FormValue("year")
-	year, err := strconv.Atoi(yearStr)
-	if err != nil {
-		WriteJSONError(w, http.StatusBadRequest, "year query parameter must be integer")
-		return
-	}
-
-	weekStr := r.FormValue("week")
-	week, err := strconv.Atoi(weekStr)
+func postPicks(username string, db pickManager, notifier nflpickem.Notifier, t TimeSource, w http.ResponseWriter, r *http.Request) {
+	year, week, err := weekParams(r)
 	if err != nil {
-		WriteJSONError(w, http.StatusBadRequest, "week query parameter must be integer")
-		return
-	}
-
-	username := r.FormValue("username")
-	if username == "" {
-		WriteJSONError(w, http.StatusBadRequest, "username is required")
+		WriteJSONError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	picks, err := db.UserPicks(username, year, week)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 
 	selections := make(nflpickem.PickSet, 0)
 	err = json.NewDecoder(r.Body).Decode(&selections)
 	if err != nil {
-		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		WriteJSONError(w, http.StatusBadRequest, "request body must be a JSON array of picks")
 		return
 	}
 
@@ -120,27 +127,25 @@ func postPicks(user nflpickem.User, db pickManager, notifier nflpickem.Notifier,
 
 	err = picks.Merge(selections)
 	if err != nil {
-		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		WriteError(w, err)
 		return
 	}
 
 	if !picks.IsLegal() {
-		WriteJSONError(w, http.StatusBadRequest, "resulting pick set contains too many point values")
+		WriteError(w, nflpickem.ErrIllegalPickSet)
 		return
 	}
 
 	err = db.MakePicks(picks)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 
-	go func() {
-		err = notifier.Notify(username, week, picks)
-		if err != nil {
-			log.Printf("unable to notify user of picks: %v", err)
-		}
-	}()
+	err = notifier.Notify(username, week, picks)
+	if err != nil {
+		log.Printf("unable to notify user of picks: %v", err)
+	}
 
 	WriteJSON(w, picks)
 }
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// pickStubService records whose picks were read.
+type pickStubService struct {
+	*stubService
+	readFor []string
+}
+
+func (db *pickStubService) UserPicks(username string, year int, week int) (nflpickem.PickSet, error) {
+	db.readFor = append(db.readFor, username)
+	return nflpickem.PickSet{}, nil
+}
+
+func TestPicksOnlyForLoggedInUser(t *testing.T) {
+	db := &pickStubService{stubService: newStubService(
+		nflpickem.User{Email: "alice@example.com"},
+		nflpickem.User{Email: "bob@example.com"},
+	)}
+	db.passwords["alice@example.com"] = "correct"
+	s := newTestServer(t, db, time.Now(), Options{})
+
+	tests := []struct {
+		method string
+		query  string
+		status int
+	}{
+		{"GET", "year=2017&week=1", http.StatusOK},
+		{"GET", "year=2017&week=1&username=alice@example.com", http.StatusOK},
+		{"GET", "year=2017&week=1&username=bob@example.com", http.StatusForbidden},
+		{"POST", "year=2017&week=1&username=bob@example.com", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, "/api/picks?"+test.query, strings.NewReader("[]"))
+		r.SetBasicAuth("alice@example.com", "correct")
+
+		if resp := serve(s, r); resp.StatusCode != test.status {
+			t.Errorf("%s %s returned %d, expected %d", test.method, test.query, resp.StatusCode, test.status)
+		}
+	}
+
+	for _, username := range db.readFor {
+		if username != "alice@example.com" {
+			t.Errorf("read the picks of %s", username)
+		}
+	}
+}
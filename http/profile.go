@@ -0,0 +1,159 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// emailVerificationLifetime is how long an e-mailed verification link may be followed.
+const emailVerificationLifetime = 24 * time.Hour
+
+// profile returns the logged in user's profile, OR updates it from the JSON representation
+// in the request body. The e-mail address is ignored; use changeEmail to change it.
+func profile(db nflpickem.ProfileManager, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+			p, err := db.Profile(user.Email, t.Now())
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			WriteJSON(w, p)
+		case "POST", "PUT":
+			var p nflpickem.Profile
+			err := json.NewDecoder(r.Body).Decode(&p)
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, "request body must be a JSON profile")
+				return
+			}
+
+			err = validateProfile(p)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			err = db.UpdateProfile(user.Email, p)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			WriteJSONSuccess(w, "Successfully updated profile")
+		default:
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only GET, POST, or PUT allowed")
+		}
+	}
+}
+
+var errNamesRequired = &nflpickem.Error{Kind: nflpickem.KindInvalid, Code: "names_required", Message: "firstName and lastName are required"}
+
+// validateProfile ensures that a profile update has names, a real time zone, and only
+// known notification events.
+func validateProfile(p nflpickem.Profile) error {
+	if p.FirstName == "" || p.LastName == "" {
+		return errNamesRequired
+	}
+
+	if p.TimeZone != "" {
+		_, err := time.LoadLocation(p.TimeZone)
+		if err != nil {
+			return nflpickem.ErrInvalidTimeZone
+		}
+	}
+
+	for event := range p.Notifications {
+		known := false
+		for _, e := range nflpickem.NotificationEvents {
+			if event == e {
+				known = true
+			}
+		}
+
+		if !known {
+			return nflpickem.ErrUnknownNotificationEvent
+		}
+	}
+
+	return nil
+}
+
+// changeEmail e-mails a verification link to the logged in user's new e-mail address. The
+// address isn't changed until the link is followed.
+//
+// Form Values:
+//	email: Required
+func changeEmail(db nflpickem.ProfileManager, notifier nflpickem.Notifier, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		email := r.FormValue("email")
+		if email == "" {
+			WriteJSONError(w, http.StatusBadRequest, "email is required")
+			return
+		}
+
+		expires := t.Now().Add(emailVerificationLifetime)
+		token, err := db.RequestEmailChange(user.Email, email, expires)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		err = notifier.NotifyEmailVerification(email, token, expires)
+		if err != nil {
+			log.Printf("unable to send e-mail verification: %v", err)
+		}
+
+		WriteJSONSuccess(w, fmt.Sprintf("Sent a verification link to %s", email))
+	}
+}
+
+// verifyEmail confirms an e-mail change using the token from the verification link.
+//
+// Form Values:
+//	token: Required
+func verifyEmail(db nflpickem.ProfileManager, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		token := r.FormValue("token")
+		if token == "" {
+			WriteJSONError(w, http.StatusBadRequest, "token is required")
+			return
+		}
+
+		err := db.ConfirmEmailChange(token, t.Now())
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, "Successfully changed e-mail address")
+	}
+}
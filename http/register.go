@@ -0,0 +1,206 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// defaultInviteLifetime is how long an invite can be used if the admin doesn't say otherwise.
+const defaultInviteLifetime = 7 * 24 * time.Hour
+
+// register adds a new user to the pool using an invite code. If the server requires
+// approval, the new user can't log in until an admin approves them.
+//
+// Form Values:
+//	code: Required
+//	firstName: Required
+//	lastName: Required
+//	email: Required
+//	password: Required
+func register(db nflpickem.Registrar, t TimeSource, requireApproval bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		code := r.FormValue("code")
+		first, last := r.FormValue("firstName"), r.FormValue("lastName")
+		email, password := r.FormValue("email"), r.FormValue("password")
+		if code == "" || first == "" || last == "" || email == "" || password == "" {
+			WriteJSONError(w, http.StatusBadRequest, "code, firstName, lastName, email, and password are required")
+			return
+		}
+
+		err := db.Register(code, t.Now(), first, last, email, password, requireApproval)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if requireApproval {
+			WriteJSONSuccess(w, "Successfully registered, your account is awaiting approval")
+			return
+		}
+
+		WriteJSONSuccess(w, "Successfully registered, please log in")
+	}
+}
+
+// createdInvite is a newly created invite, whose code is never available again.
+type createdInvite struct {
+	Code    string    `json:"code"`
+	Expires time.Time `json:"expires"`
+	MaxUses int       `json:"maxUses"`
+}
+
+// adminInvites lists all invites, OR creates a new one. The new invite's code is only
+// ever returned in the response to its creation.
+//
+// Form Values (POST):
+//	expires: duration until the invite expires, such as "72h", Optional (default 168h)
+//	uses: number of users that can register with the invite, Optional (default 1)
+func adminInvites(db nflpickem.Registrar, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			invites, err := db.Invites()
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			WriteJSON(w, invites)
+		case "POST":
+			lifetime := defaultInviteLifetime
+			if expiresStr := r.FormValue("expires"); expiresStr != "" {
+				var err error
+				lifetime, err = time.ParseDuration(expiresStr)
+				if err != nil || lifetime <= 0 {
+					WriteJSONError(w, http.StatusBadRequest, "expires must be a positive duration")
+					return
+				}
+			}
+
+			uses := 1
+			if usesStr := r.FormValue("uses"); usesStr != "" {
+				var err error
+				uses, err = strconv.Atoi(usesStr)
+				if err != nil || uses <= 0 {
+					WriteJSONError(w, http.StatusBadRequest, "uses must be a positive integer")
+					return
+				}
+			}
+
+			now := t.Now()
+			expires := now.Add(lifetime)
+
+			code, err := db.CreateInvite(now, expires, uses)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			created := createdInvite{
+				code,
+				expires,
+				uses,
+			}
+
+			WriteJSON(w, created)
+		default:
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only GET or POST allowed")
+		}
+	}
+}
+
+// adminRevokeInvite deletes an invite so that it can't be used to register.
+//
+// Form Values:
+//	id: Required
+func adminRevokeInvite(db nflpickem.Registrar) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "id must be integer")
+			return
+		}
+
+		err = db.RevokeInvite(id)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, fmt.Sprintf("Successfully revoked invite %d", id))
+	}
+}
+
+// adminPendingUsers lists the users awaiting approval.
+func adminPendingUsers(db nflpickem.Registrar) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		users, err := db.PendingUsers()
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, users)
+	}
+}
+
+// adminApproveUser activates a user awaiting approval, creating their picks for the rest
+// of the season.
+//
+// Form Values:
+//	email: Required
+func adminApproveUser(db nflpickem.Registrar, t TimeSource) http.HandlerFunc {
+	return adminDecidePending(func(email string) error {
+		return db.ApproveUser(email, t.Now())
+	}, "Successfully approved user %s")
+}
+
+// adminRejectUser removes a user awaiting approval.
+//
+// Form Values:
+//	email: Required
+func adminRejectUser(db nflpickem.Registrar) http.HandlerFunc {
+	return adminDecidePending(db.RejectUser, "Successfully rejected user %s")
+}
+
+// adminDecidePending builds a handler that applies decide to the pending user named by
+// the email form value.
+func adminDecidePending(decide func(string) error, success string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		email := r.FormValue("email")
+		if email == "" {
+			WriteJSONError(w, http.StatusBadRequest, "email is required")
+			return
+		}
+
+		err := decide(email)
+		if err == nflpickem.ErrUnknownUser {
+			WriteJSONError(w, http.StatusNotFound, "no pending user with that e-mail address")
+			return
+		} else if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, fmt.Sprintf(success, email))
+	}
+}
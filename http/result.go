@@ -2,7 +2,6 @@ package http
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/ameske/nfl-pickem"
 )
@@ -12,23 +11,15 @@ import (
 // This endpoint sorts the games by date, and sorts the list of pick results by username.
 func results(db nflpickem.ResultFetcher, t TimeSource) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		yearStr := r.FormValue("year")
-		year, err := strconv.Atoi(yearStr)
+		year, week, err := weekParams(r)
 		if err != nil {
-			WriteJSONError(w, http.StatusBadRequest, "year query parameter must be integer")
-			return
-		}
-
-		weekStr := r.FormValue("week")
-		week, err := strconv.Atoi(weekStr)
-		if err != nil {
-			WriteJSONError(w, http.StatusBadRequest, "week query parameter must be integer")
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		results, err := db.Results(t.Now(), year, week)
 		if err != nil {
-			WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			WriteError(w, err)
 			return
 		}
 
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// router dispatches requests by method and path.
+//
+// Patterns are matched a segment at a time. A segment written as {name} matches any
+// value, which the handler can retrieve with pathParam. Routes are tried in the order
+// they were registered, so literal routes such as /seasons/current must be registered
+// before parameterized routes such as /seasons/{year}.
+//
+// A request whose path matches a route, but not its method, is answered with 405 Method
+// Not Allowed and the Allow header. A request whose path doesn't match any route is
+// answered with 404 Not Found.
+type router struct {
+	routes []route
+}
+
+type route struct {
+	// method is the only method the route accepts, or empty if it accepts any method
+	method   string
+	segments []string
+	handler  http.HandlerFunc
+}
+
+type paramsKey struct{}
+
+func newRouter() *router {
+	return &router{}
+}
+
+// HandleFunc registers a handler for every method of the given pattern. The handler is
+// responsible for rejecting methods it doesn't support.
+func (rt *router) HandleFunc(pattern string, handler http.HandlerFunc) {
+	rt.Handle("", pattern, handler)
+}
+
+// Handle registers a handler for the given method and pattern. A GET handler also
+// answers HEAD requests.
+func (rt *router) Handle(method string, pattern string, handler http.HandlerFunc) {
+	rt.routes = append(rt.routes, route{
+		method:   method,
+		segments: splitPath(pattern),
+		handler:  handler,
+	})
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+	allowed := make([]string, 0)
+
+	for _, route := range rt.routes {
+		params, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+
+		if route.method == "" || route.method == r.Method || (route.method == "GET" && r.Method == "HEAD") {
+			if info := currentRequestInfo(r.Context()); info != nil {
+				info.route = "/" + strings.Join(route.segments, "/")
+			}
+
+			ctx := context.WithValue(r.Context(), paramsKey{}, params)
+			route.handler(w, r.WithContext(ctx))
+			return
+		}
+
+		allowed = appendMethod(allowed, route.method)
+		if route.method == "GET" {
+			allowed = appendMethod(allowed, "HEAD")
+		}
+	}
+
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		WriteJSONError(w, http.StatusMethodNotAllowed, r.Method+" not allowed")
+		return
+	}
+
+	WriteJSONError(w, http.StatusNotFound, "not found")
+}
+
+// appendMethod adds the method to the allowed methods unless it is already one of them.
+func appendMethod(allowed []string, method string) []string {
+	for _, m := range allowed {
+		if m == method {
+			return allowed
+		}
+	}
+
+	return append(allowed, method)
+}
+
+// match returns the route's path parameters if it matches the given path segments.
+func (rt route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, s := range rt.segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			params[s[1:len(s)-1]] = segments[i]
+		} else if s != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// pathParam returns the value of the named path parameter, and whether or not the route
+// that matched the request has it.
+func pathParam(r *http.Request, name string) (string, bool) {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	value, ok := params[name]
+	return value, ok
+}
+
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return []string{}
+	}
+
+	return strings.Split(trimmed, "/")
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterAllow(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	rt := newRouter()
+	rt.Handle("GET", "/seasons/current", ok)
+	rt.Handle("GET", "/seasons/{year}", ok)
+	rt.Handle("POST", "/seasons/{year}", ok)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{"HEAD", "/seasons/2017", http.StatusOK, ""},
+		{"DELETE", "/seasons/2017", http.StatusMethodNotAllowed, "GET, HEAD, POST"},
+		{"DELETE", "/seasons/current", http.StatusMethodNotAllowed, "GET, HEAD, POST"},
+		{"GET", "/weeks", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		if w.Code != test.status {
+			t.Errorf("%s %s returned %d, expected %d", test.method, test.path, w.Code, test.status)
+		}
+
+		if allow := w.Header().Get("Allow"); allow != test.allow {
+			t.Errorf("%s %s allows %q, expected %q", test.method, test.path, allow, test.allow)
+		}
+	}
+}
@@ -2,10 +2,10 @@ package http
 
 import (
 	"context"
-	"encoding/gob"
+	"crypto/tls"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -29,54 +29,210 @@ var DefaultTimesource = systemTime{}
 
 // A Server exposes the NFL Pickem Service over HTTP
 type Server struct {
-	address string
-	time    TimeSource
-	router  *http.ServeMux
-	sc      *securecookie.SecureCookie
-	db      nflpickem.Service
+	address  string
+	time     TimeSource
+	router   *router
+	sc       *securecookie.SecureCookie
+	db       nflpickem.Service
+	opts     Options
+	csrfKey  []byte
+	oidc     *oidcProvider
+	apiDoc   map[string]interface{}
+	hub      *hub
+	statuses *publishedStatuses
+	static   staticFiles
+	srv      *http.Server
+	notifier *backgroundNotifier
+	metrics  *metrics
+	logger   *slog.Logger
 }
 
 // NewServer creates an NFL Pickem Server at the given address, using hashKey and encryptKey for secure cookies,
-// and the given nflpickem.Service for data storage and retrieval.
-func NewServer(address string, routePrefix string, hashKey []byte, encryptKey []byte, nflService nflpickem.Service, notifier nflpickem.Notifier, t TimeSource) (*Server, error) {
+// and the given nflpickem.Service for data storage and retrieval. Optional behavior is configured by opts.
+func NewServer(address string, routePrefix string, hashKey []byte, encryptKey []byte, nflService nflpickem.Service, notifier nflpickem.Notifier, t TimeSource, opts Options) (*Server, error) {
 	sc := securecookie.New(hashKey, encryptKey)
+	sc.MaxAge(int(opts.Session.AbsoluteTimeout / time.Second))
 
 	s := &Server{
-		address: address,
-		router:  http.NewServeMux(),
-		sc:      sc,
-		db:      nflService,
-		time:    t,
+		address:  address,
+		router:   newRouter(),
+		sc:       sc,
+		db:       nflService,
+		time:     t,
+		opts:     opts,
+		csrfKey:  hashKey,
+		hub:      newHub(),
+		statuses: newPublishedStatuses(),
+		metrics:  newMetrics(),
+		logger:   opts.Logger,
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	s.notifier = &backgroundNotifier{notifier: notifier, metrics: s.metrics}
+	s.metrics.liveSubscribers = s.hub.count
+	picker := countingPicker{pickManager: nflService, metrics: s.metrics}
+
+	s.srv = &http.Server{
+		Addr:              address,
+		Handler:           s,
+		ReadHeaderTimeout: opts.Timeouts.ReadHeader,
+		ReadTimeout:       opts.Timeouts.Read,
+		WriteTimeout:      opts.Timeouts.Write,
+		IdleTimeout:       opts.Timeouts.Idle,
 	}
 
-	// Required for serialization support in github.com/gorilla/securecookie
-	gob.Register(nflpickem.User{})
+	// Operational endpoints are served outside of the API, where monitoring expects them
+	s.router.Handle("GET", "/metrics", s.serveMetrics)
+	s.router.Handle("GET", "/healthz", s.healthz)
+	s.router.Handle("GET", "/readyz", s.readyz)
 
+	s.router.Handle("GET", fmt.Sprintf("%s/openapi.json", routePrefix), s.openAPI)
 	s.router.HandleFunc(fmt.Sprintf("%s/login", routePrefix), s.login)
 	s.router.HandleFunc(fmt.Sprintf("%s/logout", routePrefix), s.logout)
+	s.router.HandleFunc(fmt.Sprintf("%s/logout/all", routePrefix), s.requireLogin(s.logoutEverywhere))
 	s.router.HandleFunc(fmt.Sprintf("%s/state", routePrefix), s.loginState)
+	s.router.HandleFunc(fmt.Sprintf("%s/oidc", routePrefix), s.oidcEnabled)
 
-	s.router.HandleFunc(fmt.Sprintf("%s/current", routePrefix), currentWeek(nflService))
-	s.router.HandleFunc(fmt.Sprintf("%s/games", routePrefix), games(nflService))
-	s.router.HandleFunc(fmt.Sprintf("%s/results", routePrefix), results(nflService, s.time))
-	s.router.HandleFunc(fmt.Sprintf("%s/totals", routePrefix), weeklyTotals(nflService))
+	if opts.OIDC.Issuer != "" {
+		s.oidc = newOIDCProvider(opts.OIDC)
+		s.router.HandleFunc(fmt.Sprintf("%s/oidc/login", routePrefix), s.oidcStart)
+		s.router.HandleFunc(fmt.Sprintf("%s/oidc/callback", routePrefix), s.oidcCallback)
+		s.router.HandleFunc(fmt.Sprintf("%s/oidc/verify", routePrefix), s.oidcVerify)
+	}
 
-	s.router.HandleFunc(fmt.Sprintf("%s/picks", routePrefix), s.requireLogin(picks(nflService, notifier, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/current", routePrefix), currentWeek(nflService))
+	s.router.HandleFunc(fmt.Sprintf("%s/games", routePrefix), s.cacheWeek(games(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/teams/standings", routePrefix), s.cacheWeek(teamStandings(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/results", routePrefix), s.cacheWeek(results(nflService, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/totals", routePrefix), s.cacheWeek(weeklyTotals(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/consensus", routePrefix), s.cacheWeek(consensus(nflService, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/simulate", routePrefix), simulate(nflService, s.time))
+	s.router.HandleFunc(fmt.Sprintf("%s/events", routePrefix), liveEvents(s.hub))
+	s.router.HandleFunc(fmt.Sprintf("%s/dashboard", routePrefix), s.requireLogin(dashboard(nflService, s.time)))
+
+	s.router.HandleFunc(fmt.Sprintf("%s/picks", routePrefix), s.requireLoginScope(nflpickem.ScopePicksWrite, picks(picker, s.notifier, s.time)))
 	s.router.HandleFunc(fmt.Sprintf("%s/password", routePrefix), s.requireLogin(changePassword(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/tokens", routePrefix), s.requireLogin(apiTokens(nflService, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/tokens/revoke", routePrefix), s.requireLogin(revokeAPIToken(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/2fa", routePrefix), s.requireLogin(twoFactorStatus(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/2fa/enroll", routePrefix), s.requireLogin(enrollTwoFactor(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/2fa/enable", routePrefix), s.requireLogin(enableTwoFactor(nflService, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/2fa/disable", routePrefix), s.requireLogin(disableTwoFactor(nflService, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/profile", routePrefix), s.requireLogin(profile(nflService, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/profile/email", routePrefix), s.requireLogin(changeEmail(nflService, s.notifier, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/profile/email/verify", routePrefix), verifyEmail(nflService, s.time))
+	s.router.HandleFunc(fmt.Sprintf("%s/register", routePrefix), register(nflService, s.time, opts.RequireApproval))
+	s.router.HandleFunc(fmt.Sprintf("%s/password/forgot", routePrefix), forgotPassword(nflService, s.notifier, s.time))
+	s.router.HandleFunc(fmt.Sprintf("%s/password/reset", routePrefix), resetPassword(nflService, s.time))
+
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/users", routePrefix), s.requireAdmin(adminUsers(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/users/disable", routePrefix), s.requireAdmin(adminDisableUser(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/users/promote", routePrefix), s.requireAdmin(adminPromoteUser(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/users/password", routePrefix), s.requireAdmin(adminResetPassword(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/users/pending", routePrefix), s.requireAdmin(adminPendingUsers(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/users/approve", routePrefix), s.requireAdmin(adminApproveUser(nflService, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/users/reject", routePrefix), s.requireAdmin(adminRejectUser(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/invites", routePrefix), s.requireAdmin(adminInvites(nflService, s.time)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/invites/revoke", routePrefix), s.requireAdmin(adminRevokeInvite(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/lockouts", routePrefix), s.requireAdmin(adminLockouts(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/lockouts/clear", routePrefix), s.requireAdmin(adminClearLockout(nflService)))
+	s.router.HandleFunc(fmt.Sprintf("%s/admin/games/score", routePrefix), s.requireAdmin(adminScoreGame(s.Updater())))
 
 	s.router.HandleFunc(fmt.Sprintf("%s/years", routePrefix), years(nflService))
+	s.router.HandleFunc(fmt.Sprintf("%s/history", routePrefix), history(nflService))
+	s.router.HandleFunc(fmt.Sprintf("%s/records", routePrefix), records(nflService))
+
+	// Version 2 of the API is organized around resources, and routes by method
+	v2 := fmt.Sprintf("%s/v2", routePrefix)
+	week := fmt.Sprintf("%s/seasons/{year}/weeks/{week}", v2)
+
+	s.router.Handle("GET", fmt.Sprintf("%s/seasons", v2), years(nflService))
+	s.router.Handle("GET", fmt.Sprintf("%s/seasons/current", v2), currentWeek(nflService))
+	s.router.Handle("GET", fmt.Sprintf("%s/dashboard", v2), s.requireLogin(dashboard(nflService, s.time)))
+	s.router.Handle("GET", fmt.Sprintf("%s/games", week), s.cacheWeek(games(nflService)))
+	s.router.Handle("GET", fmt.Sprintf("%s/results", week), s.cacheWeek(results(nflService, s.time)))
+	s.router.Handle("GET", fmt.Sprintf("%s/totals", week), s.cacheWeek(weeklyTotals(nflService)))
+	s.router.Handle("GET", fmt.Sprintf("%s/consensus", week), s.cacheWeek(consensus(nflService, s.time)))
+	s.router.Handle("GET", fmt.Sprintf("%s/simulation", week), simulate(nflService, s.time))
+	s.router.Handle("GET", fmt.Sprintf("%s/teams/standings", week), s.cacheWeek(teamStandings(nflService)))
+	s.router.Handle("GET", fmt.Sprintf("%s/events", week), liveEvents(s.hub))
+	s.router.Handle("GET", fmt.Sprintf("%s/dashboard", week), s.requireLogin(dashboard(nflService, s.time)))
+	s.router.Handle("GET", fmt.Sprintf("%s/picks", week), s.requireLogin(userPicks(nflService)))
+	s.router.Handle("POST", fmt.Sprintf("%s/picks", week), s.requireLoginScope(nflpickem.ScopePicksWrite, makePicks(picker, s.notifier, s.time)))
+	s.router.Handle("GET", fmt.Sprintf("%s/history", v2), history(nflService))
+	s.router.Handle("GET", fmt.Sprintf("%s/records", v2), records(nflService))
+
+	// Every route must be described, so that the document can be trusted by API clients
+	doc, err := newOpenAPIDocument(routePrefix, s.router.routes)
+	if err != nil {
+		return nil, err
+	}
+	s.apiDoc = doc
+
+	err = opts.TLS.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	s.static, err = newStaticFiles(opts.Assets)
+	if err != nil {
+		return nil, err
+	}
 
 	return s, nil
 }
 
-// Start starts the NFL Pickem Server
+// ServeHTTP answers requests for the web client's files, and routes every other request
+// to the API. Every request is logged and measured by the route that handled it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w}
+
+	info := &requestInfo{id: s.requestID(r), route: staticRoute}
+	w.Header().Set(requestIDHeader, info.id)
+
+	if !s.static.serve(rec, r) {
+		info.route = unmatchedRoute
+		r = r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info))
+		s.router.ServeHTTP(rec, r)
+	}
+
+	// A handler that writes nothing is answered with 200 OK
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	d := time.Since(start)
+	s.metrics.observeRequest(info.route, r.Method, status, d)
+	s.logRequest(r, info, rec, status, d)
+}
+
+// Start starts the NFL Pickem Server, serving HTTPS if it is configured. It returns
+// http.ErrServerClosed once the server is shut down.
 func (s *Server) Start() error {
-	log.Printf("NFL Pick-Em Pool listening on %s", s.address)
-	return http.ListenAndServe(s.address, s.router)
+	if !s.opts.TLS.Enabled() {
+		s.logger.Info("NFL Pick-Em Pool listening", "address", s.address)
+		return s.srv.ListenAndServe()
+	}
+
+	if s.opts.TLS.SelfSigned {
+		cert, err := selfSignedCertificate(certificateHosts(s.address), time.Now())
+		if err != nil {
+			return err
+		}
+
+		s.logger.Warn("Serving a self-signed certificate, which is only meant for development")
+		s.srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	s.logger.Info("NFL Pick-Em Pool listening with TLS", "address", s.address)
+	return s.srv.ListenAndServeTLS(s.opts.TLS.CertFile, s.opts.TLS.KeyFile)
 }
 
-// login logs a user into the NFL Pickem server, providing a secure cookie that can
-// be used for authentication of subsequent requests
+// login logs a user into the NFL Pickem server, starting a new session and providing a
+// secure cookie that identifies it on subsequent requests
 func (s *Server) login(w http.ResponseWriter, r *http.Request) {
 	u, p, ok := r.BasicAuth()
 	if !ok {
@@ -84,95 +240,149 @@ func (s *Server) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := s.db.CheckCredentials(u, p)
-	if err != nil {
-		log.Println(err)
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+	user, err := s.checkCredentials(r, u, p)
+	if lockout, ok := err.(errLockedOut); ok {
+		writeLockedOut(w, lockout, s.time.Now())
+		return
+	} else if err != nil {
+		WriteError(w, err)
 		return
 	}
 
-	cookie, err := s.newEncodedCookie("nflpickem", user)
+	setRequestUser(r, user.Email)
+
+	err = s.startSession(w, user)
 	if err != nil {
-		log.Println(err)
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 
-	http.SetCookie(w, cookie)
-
 	WriteJSONSuccess(w, "successfully logged in")
 }
 
-// newEncodedCookie creates a new new encrypted cookie containing the provided value
-func (s *Server) newEncodedCookie(name string, value interface{}) (*http.Cookie, error) {
-	encoded, err := s.sc.Encode(name, value)
-	if err != nil {
-		return nil, err
+// logout ends the user's current session and clears their cookie
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	token, err := s.sessionToken(r)
+	if err == nil {
+		err = s.db.DeleteSession(token)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
 	}
 
-	return &http.Cookie{
-		Name:     name,
-		Value:    encoded,
-		Secure:   false,
-		HttpOnly: true,
-	}, nil
+	http.SetCookie(w, s.expiredSessionCookie())
+
+	WriteJSONSuccess(w, "succesful logout")
 }
 
-// logout clears the user's cookie and logs them out from the NFL Pickem Server
-func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("nflpickem")
-	if err != nil && err != http.ErrNoCookie {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+// logoutEverywhere ends every session belonging to the logged in user, including the
+// session used to make the request
+func (s *Server) logoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
 		return
 	}
 
-	cookie.MaxAge = -1
-	http.SetCookie(w, cookie)
+	user, err := retrieveUser(r.Context())
+	if err != nil {
+		WriteJSONError(w, http.StatusUnauthorized, "login required")
+		return
+	}
 
-	WriteJSONSuccess(w, "succesful logout")
+	err = s.db.DeleteUserSessions(user.Email)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	http.SetCookie(w, s.expiredSessionCookie())
+
+	WriteJSONSuccess(w, "succesful logout from all sessions")
 }
 
-// requireLogin ensures that a user is logged before allowing access to the given endpoint
+// requireLogin ensures that a user is logged before allowing access to the given endpoint.
+// API tokens may read from the endpoint, but never change state through it.
 func (s *Server) requireLogin(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireLoginScope("", next)
+}
+
+// requireLoginScope ensures that a user is logged in before allowing access to the given
+// endpoint. Requests authenticated by an API token that may change state are only allowed
+// if the token's scope allows the given scope.
+//
+// Requests authenticated by the session cookie that may change state must also provide
+// the session's CSRF token, since a browser sends the cookie no matter which site made
+// the request.
+func (s *Server) requireLoginScope(scope nflpickem.TokenScope, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user, err := s.verifyLogin(w, r)
-		if err != nil {
+		user, err := s.verifyLogin(w, r, scope)
+		if lockout, ok := err.(errLockedOut); ok {
+			writeLockedOut(w, lockout, s.time.Now())
+			return
+		} else if err == errInvalidCSRF || err == errInsufficientScope {
+			WriteError(w, err)
+			return
+		} else if err != nil {
 			// Regardless of the path here, let's just premptively clear this cookie out
-			cookie := &http.Cookie{
-				Name:   "nflpickem",
-				MaxAge: -1,
+			http.SetCookie(w, s.expiredSessionCookie())
+
+			// Tell the client why its credentials were rejected, such as a missing second factor
+			if nflpickem.KindOf(err) == nflpickem.KindBadCredentials {
+				WriteError(w, err)
+			} else {
+				WriteJSONError(w, http.StatusUnauthorized, "login required")
 			}
-			http.SetCookie(w, cookie)
-			WriteJSONError(w, http.StatusUnauthorized, "login required")
 			return
 		}
 
+		setRequestUser(r, user.Email)
 		ctx := context.WithValue(r.Context(), "user", user)
 
 		next(w, r.WithContext(ctx))
 	}
 }
 
-func (s *Server) loginState(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("nflpickem")
-	if err != nil {
-		WriteJSONError(w, http.StatusUnauthorized, "login required")
-		return
-	}
+// requireAdmin ensures that a logged in user is an administrator before allowing access
+// to the given endpoint
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireLogin(func(w http.ResponseWriter, r *http.Request) {
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		if !user.Admin {
+			WriteJSONError(w, http.StatusForbidden, "admin required")
+			return
+		}
 
-	user := nflpickem.User{}
-	err = s.sc.Decode("nflpickem", cookie.Value, &user)
+		next(w, r)
+	})
+}
+
+// sessionState is the logged in user, along with the CSRF token that must accompany any
+// request that changes state
+type sessionState struct {
+	Name      string
+	Username  string
+	CSRFToken string
+}
+
+// loginState returns the logged in user, along with the CSRF token that must accompany
+// any request that changes state
+func (s *Server) loginState(w http.ResponseWriter, r *http.Request) {
+	user, token, err := s.currentSession(r)
 	if err != nil {
 		WriteJSONError(w, http.StatusUnauthorized, "login required")
 		return
 	}
 
-	state := struct {
-		Name     string
-		Username string
-	}{
+	state := sessionState{
 		user.FirstName,
 		user.Email,
+		s.csrfToken(token),
 	}
 
 	WriteJSON(w, state)
@@ -191,15 +401,17 @@ func retrieveUser(ctx context.Context) (nflpickem.User, error) {
 	return u, nil
 }
 
-// verifyLogin attempts to verify a user, either through a provided cookie or HTTP Basic Auth.
-// The resulting user is returned.
-func (s *Server) verifyLogin(w http.ResponseWriter, r *http.Request) (nflpickem.User, error) {
-	cookie, err := r.Cookie("nflpickem")
+// verifyLogin attempts to verify a user, either through a provided API token, session cookie,
+// or HTTP Basic Auth. A successful Basic Auth login starts a new session. The resulting user
+// is returned.
+func (s *Server) verifyLogin(w http.ResponseWriter, r *http.Request, scope nflpickem.TokenScope) (nflpickem.User, error) {
+	if token, ok := bearerToken(r); ok {
+		return s.verifyAPIToken(r, token, scope)
+	}
+
+	user, token, err := s.currentSession(r)
 	if err == nil {
-		user := nflpickem.User{}
-		if err := s.sc.Decode("nflpickem", cookie.Value, &user); err == nil {
-			return user, nil
-		}
+		return user, s.verifyCSRF(r, token)
 	}
 
 	u, p, ok := r.BasicAuth()
@@ -207,18 +419,15 @@ func (s *Server) verifyLogin(w http.ResponseWriter, r *http.Request) (nflpickem.
 		return nflpickem.User{}, errNoLogin
 	}
 
-	user, err := s.db.CheckCredentials(u, p)
+	user, err = s.checkCredentials(r, u, p)
 	if err != nil {
 		return nflpickem.User{}, err
-
 	}
 
-	cookie, err = s.newEncodedCookie("nflpickem", user)
+	err = s.startSession(w, user)
 	if err != nil {
 		return nflpickem.User{}, err
 	}
 
-	http.SetCookie(w, cookie)
-
 	return user, nil
 }
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+	"github.com/gorilla/securecookie"
+)
+
+// fixedTime is a TimeSource that always reports the same time.
+type fixedTime time.Time
+
+func (t fixedTime) Now() time.Time {
+	return time.Time(t)
+}
+
+// stubService stores just enough for the server to log users in. Any other method of
+// nflpickem.Service panics, since the embedded interface is nil.
+type stubService struct {
+	nflpickem.Service
+
+	users     map[string]nflpickem.User
+	passwords map[string]string
+	totp      map[string]nflpickem.TOTP
+	recovery  map[string]map[string]bool
+	lockouts  map[nflpickem.LockoutKind]map[string]nflpickem.Lockout
+	sessions  map[string]string
+}
+
+func newStubService(users ...nflpickem.User) *stubService {
+	db := &stubService{
+		users:     make(map[string]nflpickem.User),
+		passwords: make(map[string]string),
+		totp:      make(map[string]nflpickem.TOTP),
+		recovery:  make(map[string]map[string]bool),
+		lockouts: map[nflpickem.LockoutKind]map[string]nflpickem.Lockout{
+			nflpickem.AccountLockout: make(map[string]nflpickem.Lockout),
+			nflpickem.AddressLockout: make(map[string]nflpickem.Lockout),
+		},
+		sessions: make(map[string]string),
+	}
+
+	for _, u := range users {
+		db.users[u.Email] = u
+	}
+
+	return db
+}
+
+func (db *stubService) User(username string) (nflpickem.User, error) {
+	u, ok := db.users[username]
+	if !ok {
+		return nflpickem.User{}, nflpickem.ErrUnknownUser
+	}
+
+	return u, nil
+}
+
+func (db *stubService) CheckCredentials(username string, password string) (nflpickem.User, error) {
+	u, ok := db.users[username]
+	if !ok || db.passwords[username] != password {
+		return nflpickem.User{}, nflpickem.ErrBadCredentials
+	}
+
+	return u, nil
+}
+
+func (db *stubService) TOTP(username string) (nflpickem.TOTP, error) {
+	o, ok := db.totp[username]
+	if !ok {
+		return nflpickem.TOTP{}, nflpickem.ErrTOTPNotEnrolled
+	}
+
+	return o, nil
+}
+
+func (db *stubService) UseTOTPStep(username string, step int64) error {
+	o := db.totp[username]
+	if step <= o.LastStep {
+		return nflpickem.ErrInvalidTOTP
+	}
+
+	o.LastStep = step
+	db.totp[username] = o
+
+	return nil
+}
+
+func (db *stubService) RedeemRecoveryCode(username string, code string) error {
+	if !db.recovery[username][code] {
+		return nflpickem.ErrInvalidRecoveryCode
+	}
+
+	delete(db.recovery[username], code)
+
+	return nil
+}
+
+func (db *stubService) Lockout(kind nflpickem.LockoutKind, key string) (nflpickem.Lockout, error) {
+	l, ok := db.lockouts[kind][key]
+	if !ok {
+		return nflpickem.Lockout{Kind: kind, Key: key}, nil
+	}
+
+	return l, nil
+}
+
+func (db *stubService) SaveLockout(l nflpickem.Lockout) error {
+	db.lockouts[l.Kind][l.Key] = l
+	return nil
+}
+
+func (db *stubService) ClearLockout(kind nflpickem.LockoutKind, key string) error {
+	delete(db.lockouts[kind], key)
+	return nil
+}
+
+func (db *stubService) CreateSession(username string, t time.Time) (string, error) {
+	token := string(securecookie.GenerateRandomKey(16))
+	db.sessions[token] = username
+
+	return token, nil
+}
+
+// newTestServer creates a server for db that logs nothing, with opts filled in with the
+// defaults where they are needed.
+func newTestServer(t *testing.T, db nflpickem.Service, now time.Time, opts Options) *Server {
+	if opts.Session.AbsoluteTimeout == 0 {
+		opts.Session.AbsoluteTimeout = 24 * time.Hour
+	}
+	if opts.AccountLockout.Threshold == 0 {
+		opts.AccountLockout = nflpickem.LockoutPolicy{Threshold: 5, Base: time.Minute, Max: time.Hour, Reset: time.Hour}
+	}
+	if opts.AddressLockout.Threshold == 0 {
+		opts.AddressLockout = opts.AccountLockout
+	}
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	s, err := NewServer("127.0.0.1:0", "/api", securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(32), db, nil, fixedTime(now), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// serve sends the request to the server, along with the given cookies, and returns the
+// response.
+func serve(s *Server, r *http.Request, cookies ...*http.Cookie) *http.Response {
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	return w.Result()
+}
+
+// responseCookie returns the cookie with the given name set by the response, or nil.
+func responseCookie(resp *http.Response, name string) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+const sessionCookieName = "nflpickem"
+
+// sessionTouchInterval limits how often a session's last use is written to the datastore.
+const sessionTouchInterval = time.Minute
+
+// SessionOptions controls how long login sessions last and how their cookie is sent.
+type SessionOptions struct {
+	// IdleTimeout ends a session that hasn't been used for the given duration
+	IdleTimeout time.Duration
+	// AbsoluteTimeout ends a session the given duration after login, regardless of use
+	AbsoluteTimeout time.Duration
+	// SecureCookie restricts the session cookie to HTTPS connections
+	SecureCookie bool
+	// SameSite restricts the session cookie from being sent on cross-site requests
+	SameSite http.SameSite
+}
+
+// DefaultSessionOptions keeps a user logged in for a week without activity, and requires
+// a new login every thirty days.
+var DefaultSessionOptions = SessionOptions{
+	IdleTimeout:     7 * 24 * time.Hour,
+	AbsoluteTimeout: 30 * 24 * time.Hour,
+	SecureCookie:    false,
+	SameSite:        http.SameSiteLaxMode,
+}
+
+// ParseSameSite converts the name of a SameSite mode, "lax", "strict", or "none", into
+// its http.SameSite value.
+func ParseSameSite(mode string) (http.SameSite, error) {
+	switch mode {
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return http.SameSiteDefaultMode, fmt.Errorf("unrecognized SameSite mode: %s", mode)
+	}
+}
+
+var (
+	errSessionExpired = errors.New("session expired")
+	errInvalidCSRF    = &nflpickem.Error{Kind: nflpickem.KindForbidden, Code: "invalid_csrf_token", Message: "missing or invalid CSRF token"}
+)
+
+// csrfHeader is the request header that must carry the session's CSRF token on
+// requests that change state.
+const csrfHeader = "X-CSRF-Token"
+
+// startSession creates a new session for the user and sets the cookie identifying it. The
+// session's CSRF token is sent in the X-CSRF-Token response header.
+func (s *Server) startSession(w http.ResponseWriter, user nflpickem.User) error {
+	token, err := s.db.CreateSession(user.Email, s.time.Now())
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set(csrfHeader, s.csrfToken(token))
+
+	encoded, err := s.sc.Encode(sessionCookieName, token)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    encoded,
+		Path:     "/",
+		MaxAge:   int(s.opts.Session.AbsoluteTimeout / time.Second),
+		Secure:   s.opts.Session.SecureCookie,
+		HttpOnly: true,
+		SameSite: s.opts.Session.SameSite,
+	})
+
+	return nil
+}
+
+// sessionToken extracts the session token from the request's cookie.
+func (s *Server) sessionToken(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return "", err
+	}
+
+	var token string
+	err = s.sc.Decode(sessionCookieName, cookie.Value, &token)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// currentSession validates the session identified by the request's cookie, returning the
+// current information for its user along with the session's token. Expired sessions and
+// sessions of disabled users are removed from the datastore.
+func (s *Server) currentSession(r *http.Request) (nflpickem.User, string, error) {
+	token, err := s.sessionToken(r)
+	if err != nil {
+		return nflpickem.User{}, "", err
+	}
+
+	session, err := s.db.Session(token)
+	if err != nil {
+		return nflpickem.User{}, "", err
+	}
+
+	now := s.time.Now()
+	if session.User.Disabled || now.Sub(session.LastSeen) > s.opts.Session.IdleTimeout || now.Sub(session.Created) > s.opts.Session.AbsoluteTimeout {
+		err := s.db.DeleteSession(token)
+		if err != nil {
+			log.Println(err)
+		}
+		return nflpickem.User{}, "", errSessionExpired
+	}
+
+	if now.Sub(session.LastSeen) > sessionTouchInterval {
+		err := s.db.TouchSession(token, now)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	return session.User, token, nil
+}
+
+// expiredSessionCookie returns a cookie that clears the session cookie from the client.
+func (s *Server) expiredSessionCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     sessionCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   s.opts.Session.SecureCookie,
+		HttpOnly: true,
+		SameSite: s.opts.Session.SameSite,
+	}
+}
+
+// csrfToken derives the CSRF token for the session identified by the given token. The
+// CSRF token can't be forged without the server's hash key, and is only valid for the
+// session it was derived from.
+func (s *Server) csrfToken(sessionToken string) string {
+	mac := hmac.New(sha256.New, s.csrfKey)
+	mac.Write([]byte(sessionToken))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyCSRF ensures that a request that may change state carries the CSRF token of the
+// session it was authenticated with. Safe methods are always allowed.
+func (s *Server) verifyCSRF(r *http.Request, sessionToken string) error {
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return nil
+	}
+
+	provided := r.Header.Get(csrfHeader)
+	if provided == "" || !hmac.Equal([]byte(provided), []byte(s.csrfToken(sessionToken))) {
+		return errInvalidCSRF
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// backgroundNotifier sends notifications without making requests wait for them. Failures
+// are logged, since the request that caused the notification has already been answered.
+//
+// The notifications still being sent are tracked, so that shutting down can wait for them
+// instead of cutting them off mid-send.
+type backgroundNotifier struct {
+	notifier nflpickem.Notifier
+	metrics  *metrics
+	pending  sync.WaitGroup
+}
+
+func (n *backgroundNotifier) Notify(to string, week int, picks []nflpickem.Pick) error {
+	n.send("picks", "notify user of picks", func() error {
+		return n.notifier.Notify(to, week, picks)
+	})
+
+	return nil
+}
+
+func (n *backgroundNotifier) NotifyPasswordReset(to string, token string, expires time.Time) error {
+	n.send("password_reset", "send password reset", func() error {
+		return n.notifier.NotifyPasswordReset(to, token, expires)
+	})
+
+	return nil
+}
+
+func (n *backgroundNotifier) NotifyEmailVerification(to string, token string, expires time.Time) error {
+	n.send("email_verification", "send e-mail verification", func() error {
+		return n.notifier.NotifyEmailVerification(to, token, expires)
+	})
+
+	return nil
+}
+
+// send notifies in the background, counting the result by the kind of notification and
+// logging what action failed.
+func (n *backgroundNotifier) send(kind string, action string, notify func() error) {
+	n.pending.Add(1)
+
+	go func() {
+		defer n.pending.Done()
+
+		err := notify()
+		n.metrics.notification(kind, err)
+		if err != nil {
+			log.Printf("unable to %s: %v", action, err)
+		}
+	}()
+}
+
+// flush waits for every pending notification to be sent, or for ctx to be done.
+func (n *backgroundNotifier) flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown gracefully stops the server. It stops accepting connections, ends live event
+// streams, and waits for requests in progress to finish and then for pending notifications
+// to be sent. If ctx is done first, the remaining connections are closed and its error
+// is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.hub.close()
+
+	err := s.srv.Shutdown(ctx)
+	if err != nil {
+		s.srv.Close()
+		return err
+	}
+
+	return s.notifier.flush(ctx)
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+const (
+	defaultSimulationTrials = 10000
+	maxSimulationTrials     = 100000
+)
+
+// Simulate returns each user's probability of winning the given week, found by
+// simulating the outcome of every game that hasn't finished.
+//
+// URL Parameters:
+//	year: Specifies the current year, Required
+//	week: Specifies the current week, Required
+//	model: ["coin", "record"], how unplayed games are decided, Optional
+//	trials: number of simulated weeks, Optional
+//	seed: seed for the simulation, making the result repeatable, Optional
+func simulate(db nflpickem.WinSimulator, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		year, week, err := weekParams(r)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		model := nflpickem.OddsModel(r.FormValue("model"))
+		if model == "" {
+			model = nflpickem.CoinFlip
+		}
+
+		trials := defaultSimulationTrials
+		if trialsStr := r.FormValue("trials"); trialsStr != "" {
+			trials, err = strconv.Atoi(trialsStr)
+			if err != nil || trials <= 0 || trials > maxSimulationTrials {
+				WriteJSONError(w, http.StatusBadRequest, "trials query parameter must be a positive integer no greater than "+strconv.Itoa(maxSimulationTrials))
+				return
+			}
+		}
+
+		seed := t.Now().UnixNano()
+		if seedStr := r.FormValue("seed"); seedStr != "" {
+			seed, err = strconv.ParseInt(seedStr, 10, 64)
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, "seed query parameter must be integer")
+				return
+			}
+		}
+
+		probabilities, err := db.SimulateWeek(t.Now(), year, week, model, trials, seed)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, probabilities)
+	}
+}
@@ -0,0 +1,170 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// staticFile is a web asset, read and compressed once when the server starts.
+type staticFile struct {
+	name        string
+	contentType string
+	etag        string
+	content     []byte
+	// gzipped is nil if compressing the file doesn't make it smaller
+	gzipped []byte
+}
+
+// staticFiles are the web assets served by the server, keyed by their URL path.
+type staticFiles map[string]staticFile
+
+// newStaticFiles loads every file of the given file systems, served under the URL path
+// prefix each is keyed by.
+func newStaticFiles(assets map[string]fs.FS) (staticFiles, error) {
+	files := make(staticFiles)
+
+	for prefix, fsys := range assets {
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			content, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+
+			f, err := newStaticFile(name, content)
+			if err != nil {
+				return err
+			}
+
+			files[path.Join("/", prefix, name)] = f
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+func newStaticFile(name string, content []byte) (staticFile, error) {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+
+	sum := sha256.Sum256(content)
+
+	f := staticFile{
+		name:        name,
+		contentType: contentType,
+		etag:        fmt.Sprintf("%x", sum[:12]),
+		content:     content,
+	}
+
+	if !compressible(contentType) {
+		return f, nil
+	}
+
+	var b bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&b, gzip.BestCompression)
+	if err != nil {
+		return staticFile{}, err
+	}
+
+	_, err = gz.Write(content)
+	if err != nil {
+		return staticFile{}, err
+	}
+
+	err = gz.Close()
+	if err != nil {
+		return staticFile{}, err
+	}
+
+	if b.Len() < len(content) {
+		f.gzipped = b.Bytes()
+	}
+
+	return f, nil
+}
+
+// compressible returns whether content of the given type is worth compressing. Images
+// such as the team logos are already compressed.
+func compressible(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "application/javascript", "application/json", "image/svg+xml":
+		return true
+	}
+
+	return strings.HasPrefix(mediaType, "text/")
+}
+
+// serve writes the web asset at the request's path, returning false if there isn't one so
+// that the request can be routed to the API instead. A path ending in a slash serves the
+// directory's index.html.
+//
+// Assets aren't fingerprinted, so clients must revalidate them on every use, which their
+// ETag makes cheap.
+func (files staticFiles) serve(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return false
+	}
+
+	name := r.URL.Path
+	if strings.HasSuffix(name, "/") {
+		name += "index.html"
+	}
+
+	f, ok := files[name]
+	if !ok {
+		return false
+	}
+
+	content, etag := f.content, f.etag
+	if f.gzipped != nil {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if acceptsGzip(r) {
+			content, etag = f.gzipped, etag+"-gzip"
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+	}
+
+	w.Header().Set("Content-Type", f.contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", `"`+etag+`"`)
+
+	http.ServeContent(w, r, f.name, time.Time{}, bytes.NewReader(content))
+
+	return true
+}
+
+// acceptsGzip returns whether the client accepts gzip content encoding.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if name != "gzip" && name != "*" {
+			continue
+		}
+
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+
+	return false
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// TeamStandings returns the records of every NFL team for a season.
+//
+// URL Parameters:
+//	year: Specifies the current year, Required
+//	week: Specifies the last week of games to count, Required
+func teamStandings(db nflpickem.TeamStandingsFetcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		year, week, err := weekParams(r)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		standings, err := db.TeamStandings(year, week)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, standings)
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"time"
+)
+
+// selfSignedValidity is how long a generated self-signed certificate is valid for. A new
+// one is generated every time the server starts.
+const selfSignedValidity = 30 * 24 * time.Hour
+
+// TLSOptions configures serving HTTPS. The server serves plain HTTP if neither a
+// certificate nor SelfSigned is configured.
+type TLSOptions struct {
+	// CertFile and KeyFile are PEM encoded files holding the certificate chain and its key
+	CertFile string
+	KeyFile  string
+	// SelfSigned generates a certificate for the listen address and localhost when the
+	// server starts. Browsers will warn about it, so it is only meant for development.
+	SelfSigned bool
+}
+
+// Enabled returns whether the options call for HTTPS.
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" || o.SelfSigned
+}
+
+// Validate returns an error if the options are incomplete or contradictory.
+func (o TLSOptions) Validate() error {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return errors.New("TLS requires both a certificate and key file")
+	}
+
+	if o.CertFile != "" && o.SelfSigned {
+		return errors.New("TLS can't use both a certificate file and a self-signed certificate")
+	}
+
+	return nil
+}
+
+// selfSignedCertificate generates a certificate, signed by its own key, for the given host
+// names and IP addresses.
+func selfSignedCertificate(hosts []string, now time.Time) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"NFL Pick-Em Pool (development)"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// certificateHosts returns the names a self-signed certificate for the given listen
+// address is issued for.
+func certificateHosts(address string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil || host == "" {
+		return hosts
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		return hosts
+	}
+
+	for _, h := range hosts {
+		if h == host {
+			return hosts
+		}
+	}
+
+	return append(hosts, host)
+}
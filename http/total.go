@@ -3,25 +3,28 @@ package http
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/ameske/nfl-pickem"
 )
 
+// totalsFetcher is the interface that defines the ability to retrieve totals and outlooks
+type totalsFetcher interface {
+	nflpickem.WeekTotalFetcher
+	nflpickem.OutlookFetcher
+}
+
 // WeeklyTotals returns the current point totals for all users for a given year and week.
-func weeklyTotals(db nflpickem.WeekTotalFetcher) http.HandlerFunc {
+//
+// URL Parameters:
+//	year: Specifies the current year, Required
+//	week: Specifies the current week, Required
+//	type: ["cumulative", "outlook"], returns totals up to the given week, or each user's
+//	      remaining and maximum points for the week and season, Optional
+func weeklyTotals(db totalsFetcher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		yearStr := r.FormValue("year")
-		year, err := strconv.Atoi(yearStr)
+		year, week, err := weekParams(r)
 		if err != nil {
-			WriteJSONError(w, http.StatusBadRequest, "year query parameter must be integer")
-			return
-		}
-
-		weekStr := r.FormValue("week")
-		week, err := strconv.Atoi(weekStr)
-		if err != nil {
-			WriteJSONError(w, http.StatusBadRequest, "week query parameter must be integer")
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
@@ -34,13 +37,22 @@ func weeklyTotals(db nflpickem.WeekTotalFetcher) http.HandlerFunc {
 			totals, err = db.WeekTotals(year, week)
 		case "cumulative":
 			totals, err = db.CumulativeWeekTotals(year, week)
+		case "outlook":
+			outlooks, err := db.Outlook(year, week)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			WriteJSON(w, outlooks)
+			return
 		default:
 			WriteJSONError(w, http.StatusBadRequest, fmt.Sprintf("unknown kind parameter [%s]", kind))
 			return
 		}
 
 		if err != nil {
-			WriteJSONError(w, http.StatusInternalServerError, err.Error())
+			WriteError(w, err)
 			return
 		}
 
@@ -0,0 +1,221 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// otpHeader is the request header that carries a two-factor authentication code, or a
+// recovery code, when logging in.
+const otpHeader = "X-OTP"
+
+// totpIssuer identifies the pool in a user's authenticator app.
+const totpIssuer = "NFL Pickem"
+
+// verifySecondFactor ensures that the request carries a valid second factor for the user,
+// if they have enabled two-factor authentication.
+func (s *Server) verifySecondFactor(r *http.Request, user nflpickem.User) error {
+	o, err := s.db.TOTP(user.Email)
+	if err == nflpickem.ErrTOTPNotEnrolled {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !o.Enabled {
+		return nil
+	}
+
+	code := r.Header.Get(otpHeader)
+	if code == "" {
+		return nflpickem.ErrTOTPRequired
+	}
+
+	return verifyCode(s.db, user.Email, o, code, s.time)
+}
+
+// verifyCode accepts either a TOTP code or one of the user's unused recovery codes. Either
+// can only be used once.
+func verifyCode(db nflpickem.TwoFactorManager, username string, o nflpickem.TOTP, code string, t TimeSource) error {
+	if len(code) != nflpickem.TOTPDigits {
+		return db.RedeemRecoveryCode(username, code)
+	}
+
+	step, err := o.Verify(code, t.Now())
+	if err != nil {
+		return err
+	}
+
+	return db.UseTOTPStep(username, step)
+}
+
+// twoFactorStatus reports whether or not the logged in user has enabled two-factor
+// authentication.
+func twoFactorStatus(db nflpickem.TwoFactorManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		o, err := db.TOTP(user.Email)
+		if err != nil && err != nflpickem.ErrTOTPNotEnrolled {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, o)
+	}
+}
+
+// twoFactorEnrollment is a new TOTP secret, along with the provisioning URI that adds it to
+// an authenticator app.
+type twoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// enrollTwoFactor generates a new TOTP secret for the logged in user, returning it along
+// with the provisioning URI for their authenticator app. The second factor isn't required
+// until the enrollment is confirmed with enableTwoFactor.
+func enrollTwoFactor(db nflpickem.TwoFactorManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		o, err := db.TOTP(user.Email)
+		if err != nil && err != nflpickem.ErrTOTPNotEnrolled {
+			WriteError(w, err)
+			return
+		} else if o.Enabled {
+			WriteJSONError(w, http.StatusConflict, "two-factor authentication is already enabled")
+			return
+		}
+
+		secret, err := nflpickem.NewTOTPSecret()
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		err = db.EnrollTOTP(user.Email, secret)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		enrollment := twoFactorEnrollment{
+			secret,
+			nflpickem.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+		}
+
+		WriteJSON(w, enrollment)
+	}
+}
+
+// recoveryCodes are the single use codes that may stand in for a TOTP code.
+type recoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// enableTwoFactor confirms the logged in user's enrollment with a code from their
+// authenticator app, and returns their recovery codes. The recovery codes are never
+// available again.
+//
+// Form Values:
+//	code: Required
+func enableTwoFactor(db nflpickem.TwoFactorManager, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		o, err := db.TOTP(user.Email)
+		if err != nil {
+			WriteError(w, err)
+			return
+		} else if o.Enabled {
+			WriteJSONError(w, http.StatusConflict, "two-factor authentication is already enabled")
+			return
+		}
+
+		step, err := o.Verify(r.FormValue("code"), t.Now())
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, nflpickem.ErrInvalidTOTP.Error())
+			return
+		}
+
+		err = db.UseTOTPStep(user.Email, step)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, nflpickem.ErrInvalidTOTP.Error())
+			return
+		}
+
+		codes, err := db.EnableTOTP(user.Email)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSON(w, recoveryCodes{codes})
+	}
+}
+
+// disableTwoFactor stops requiring the second factor for the logged in user. A current
+// code, or a recovery code, is required so that a stolen session can't remove it.
+//
+// Form Values:
+//	code: Required
+func disableTwoFactor(db nflpickem.TwoFactorManager, t TimeSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			WriteJSONError(w, http.StatusMethodNotAllowed, "only POST allowed")
+			return
+		}
+
+		user, err := retrieveUser(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		o, err := db.TOTP(user.Email)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if o.Enabled {
+			err = verifyCode(db, user.Email, o, r.FormValue("code"), t)
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, "invalid two-factor authentication or recovery code")
+				return
+			}
+		}
+
+		err = db.DisableTOTP(user.Email)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		WriteJSONSuccess(w, "Successfully disabled two-factor authentication")
+	}
+}
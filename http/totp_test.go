@@ -0,0 +1,59 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+func TestVerifyCode(t *testing.T) {
+	now := time.Date(2017, time.September, 10, 13, 0, 10, 0, time.UTC)
+	step := nflpickem.TOTPStep(now)
+
+	secret, err := nflpickem.NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := func(step int64) string {
+		c, err := nflpickem.TOTPCode(secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	db := newStubService(nflpickem.User{Email: "alice@example.com"})
+	db.totp["alice@example.com"] = nflpickem.TOTP{Secret: secret, Enabled: true}
+	db.recovery["alice@example.com"] = map[string]bool{"abcd-efgh": true}
+
+	// verify checks the code against the enrollment as currently stored, as a login would
+	verify := func(code string) error {
+		return verifyCode(db, "alice@example.com", db.totp["alice@example.com"], code, fixedTime(now))
+	}
+
+	steps := []struct {
+		name string
+		code string
+		err  error
+	}{
+		{"code from two steps ago", code(step - 2), nflpickem.ErrInvalidTOTP},
+		{"code from the previous step", code(step - 1), nil},
+		{"replayed code", code(step - 1), nflpickem.ErrInvalidTOTP},
+		{"code from the current step", code(step), nil},
+		{"code older than the last used", code(step - 1), nflpickem.ErrInvalidTOTP},
+		{"code from two steps ahead", code(step + 2), nflpickem.ErrInvalidTOTP},
+		{"code from the next step", code(step + 1), nil},
+		{"recovery code", "abcd-efgh", nil},
+		{"reused recovery code", "abcd-efgh", nflpickem.ErrInvalidRecoveryCode},
+		{"unknown recovery code", "zzzz-zzzz", nflpickem.ErrInvalidRecoveryCode},
+	}
+
+	// The steps build on each other, so they run in order as a single test
+	for _, s := range steps {
+		if err := verify(s.code); err != s.err {
+			t.Errorf("%s: got %v, expected %v", s.name, err, s.err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package nflpickem
+
+import "time"
+
+// Invite allows new players to register for the pool themselves. The code identifying an
+// invite is only available when it is created.
+type Invite struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+	MaxUses int       `json:"maxUses"`
+	Uses    int       `json:"uses"`
+}
+
+var (
+	ErrInvalidInvite = newError(KindForbidden, "invalid_invite", "invalid, expired, or used up invite code")
+	ErrUnknownInvite = newError(KindNotFound, "unknown_invite", "unknown invite")
+	ErrUserExists    = newError(KindConflict, "user_exists", "a user with that e-mail address already exists")
+	ErrUserPending   = newError(KindForbidden, "user_pending", "user is awaiting approval")
+)
+
+// Registrar is the interface implemented by types that can register new users with an
+// invite code, and manage the users awaiting approval.
+//
+// A user is activated when they are able to log in, which happens either when they
+// register or, if approval is required, when they are approved. Activating a user creates
+// their picks for the rest of the current season.
+type Registrar interface {
+	CreateInvite(t time.Time, expires time.Time, maxUses int) (code string, err error)
+	Invites() ([]Invite, error)
+	RevokeInvite(id int64) error
+	Register(code string, t time.Time, first string, last string, email string, password string, pending bool) error
+	PendingUsers() ([]User, error)
+	ApproveUser(username string, t time.Time) error
+	RejectUser(username string) error
+}
@@ -0,0 +1,71 @@
+package nflpickem
+
+import "time"
+
+// LockoutKind identifies what a Lockout applies to.
+type LockoutKind string
+
+const (
+	// AccountLockout tracks failed logins for a single username
+	AccountLockout LockoutKind = "account"
+	// AddressLockout tracks failed logins from a single IP address
+	AddressLockout LockoutKind = "ip"
+)
+
+// Lockout records the recent failed logins for an account or IP address, and how long
+// further logins are refused.
+type Lockout struct {
+	Kind        LockoutKind `json:"kind"`
+	Key         string      `json:"key"`
+	Failures    int         `json:"failures"`
+	LastFailure time.Time   `json:"lastFailure"`
+	LockedUntil time.Time   `json:"lockedUntil"`
+}
+
+// Locked returns whether or not logins are refused at time t.
+func (l Lockout) Locked(t time.Time) bool {
+	return t.Before(l.LockedUntil)
+}
+
+// LockoutPolicy decides how long to refuse logins after repeated failures.
+//
+// Once Threshold failures have been recorded, logins are refused for Base, doubling with
+// every further failure up to Max. Failures are forgotten once Reset has passed without
+// another failure.
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	Reset     time.Duration
+}
+
+// Fail returns the lockout updated with a failed login at time t.
+func (p LockoutPolicy) Fail(l Lockout, t time.Time) Lockout {
+	if t.Sub(l.LastFailure) > p.Reset {
+		l.Failures = 0
+	}
+
+	l.Failures++
+	l.LastFailure = t
+
+	if l.Failures >= p.Threshold {
+		d := p.Base
+		for i := p.Threshold; i < l.Failures && d < p.Max; i++ {
+			d *= 2
+		}
+		if d > p.Max {
+			d = p.Max
+		}
+		l.LockedUntil = t.Add(d)
+	}
+
+	return l
+}
+
+// LockoutManager is the interface implemented by types that can store login lockouts.
+type LockoutManager interface {
+	Lockout(kind LockoutKind, key string) (Lockout, error)
+	SaveLockout(l Lockout) error
+	ClearLockout(kind LockoutKind, key string) error
+	Lockouts() ([]Lockout, error)
+}
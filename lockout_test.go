@@ -0,0 +1,65 @@
+package nflpickem
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyFail(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 3, Base: time.Minute, Max: 10 * time.Minute, Reset: time.Hour}
+	start := time.Date(2017, time.September, 10, 13, 0, 0, 0, time.UTC)
+
+	// Each failure comes a second after the last, so that they're never forgotten
+	lockedFor := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+
+	l := Lockout{Kind: AccountLockout, Key: "alice@example.com"}
+	for i, d := range lockedFor {
+		now := start.Add(time.Duration(i) * time.Second)
+		l = policy.Fail(l, now)
+
+		if l.Failures != i+1 {
+			t.Fatalf("failure %d: recorded %d failures", i+1, l.Failures)
+		}
+
+		if d == 0 {
+			if l.Locked(now) {
+				t.Errorf("failure %d: locked before reaching the threshold", i+1)
+			}
+			continue
+		}
+
+		if until := now.Add(d); !l.LockedUntil.Equal(until) {
+			t.Errorf("failure %d: locked for %s, expected %s", i+1, l.LockedUntil.Sub(now), d)
+		}
+
+		if !l.Locked(now.Add(d - time.Second)) {
+			t.Errorf("failure %d: lockout ended early", i+1)
+		}
+
+		if l.Locked(now.Add(d)) {
+			t.Errorf("failure %d: lockout didn't expire after %s", i+1, d)
+		}
+	}
+}
+
+func TestLockoutPolicyReset(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 2, Base: time.Minute, Max: time.Hour, Reset: time.Hour}
+	start := time.Date(2017, time.September, 10, 13, 0, 0, 0, time.UTC)
+
+	l := policy.Fail(Lockout{}, start)
+	l = policy.Fail(l, start.Add(time.Minute))
+	if !l.Locked(start.Add(time.Minute)) {
+		t.Fatal("not locked after reaching the threshold")
+	}
+
+	later := start.Add(time.Minute + policy.Reset + time.Second)
+	l = policy.Fail(l, later)
+
+	if l.Failures != 1 {
+		t.Errorf("recorded %d failures, expected earlier failures to be forgotten", l.Failures)
+	}
+
+	if l.Locked(later) {
+		t.Error("locked by a single failure after the earlier ones were forgotten")
+	}
+}
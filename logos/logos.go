@@ -0,0 +1,9 @@
+// Package logos embeds the logos of every NFL team, so that they are served by nfld itself.
+package logos
+
+import "embed"
+
+// FS holds a GIF logo for each team, named by the team's abbreviation.
+//
+//go:embed *.gif
+var FS embed.FS
@@ -1,27 +1,58 @@
 package nflpickem
 
+import (
+	"context"
+	"time"
+)
+
 // Service is the interface implemented by types that can provide
 // all the various services needed by the NFL Pickem Pool
 type Service interface {
 	Weeker
 	GamesRetriever
+	Updater
+	TeamStandingsFetcher
 	PasswordUpdater
+	PasswordResetter
 	Picker
 	PickRetriever
 	ResultFetcher
+	ConsensusFetcher
 	WeekTotalFetcher
+	OutlookFetcher
+	WinSimulator
+	DashboardFetcher
+	Versioner
 	CredentialChecker
+	SessionManager
+	LockoutManager
+	APITokenManager
+	TwoFactorManager
+	Registrar
+	ProfileManager
 	DataSummarizer
-	UserAdder
+	HealthChecker
+	UserManager
 	GameAdder
 	DateAdder
 	PickCreater
 }
 
+// HealthChecker is the interface implemented by types that can check that their storage
+// can be reached.
+type HealthChecker interface {
+	PingContext(ctx context.Context) error
+}
+
+// Notifier is the interface implemented by types that can notify users of changes to
+// their account or picks.
 type Notifier interface {
 	Notify(to string, week int, picks []Pick) error
+	NotifyPasswordReset(to string, token string, expires time.Time) error
+	NotifyEmailVerification(to string, token string, expires time.Time) error
 }
 
 type DataSummarizer interface {
 	Years() ([]int, error)
+	HistoryFetcher
 }
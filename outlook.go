@@ -0,0 +1,83 @@
+package nflpickem
+
+// Outlook is a user's earned points for a week and season, along with the points they
+// can still win and whether or not they can still finish first.
+type Outlook struct {
+	User             User `json:"user"`
+	Year             int  `json:"year"`
+	Week             int  `json:"week"`
+	WeekTotal        int  `json:"weekTotal"`
+	WeekRemaining    int  `json:"weekRemaining"`
+	WeekMax          int  `json:"weekMax"`
+	WeekEliminated   bool `json:"weekEliminated"`
+	SeasonTotal      int  `json:"seasonTotal"`
+	SeasonRemaining  int  `json:"seasonRemaining"`
+	SeasonMax        int  `json:"seasonMax"`
+	SeasonEliminated bool `json:"seasonEliminated"`
+}
+
+// OutlookFetcher is the interface implemented by types that can calculate every user's
+// outlook for a given week of the season.
+type OutlookFetcher interface {
+	Outlook(year int, week int) ([]Outlook, error)
+}
+
+// NewOutlooks calculates the outlook of every user for the given week.
+//
+// picks must contain every user's picks for the week, and totals the cumulative totals
+// of the season through the week. futureMax is the most points that can be earned in
+// the weeks of the season after the given week.
+//
+// Points are at stake for a pick until its game is final. Points for a later week are
+// counted as if every pick will be correct. A user is eliminated once their maximum
+// total falls below the total already earned by the leader.
+func NewOutlooks(year int, week int, picks PickSet, totals []WeekTotal, futureMax int) []Outlook {
+	outlooks := make([]Outlook, 0)
+	index := make(map[string]int)
+
+	outlook := func(u User) *Outlook {
+		i, ok := index[u.Email]
+		if !ok {
+			i = len(outlooks)
+			index[u.Email] = i
+			outlooks = append(outlooks, Outlook{User: u, Year: year, Week: week})
+		}
+		return &outlooks[i]
+	}
+
+	for _, p := range picks {
+		o := outlook(p.User)
+		switch {
+		case p.Correct():
+			o.WeekTotal += p.Points
+		case !p.Game.Final():
+			o.WeekRemaining += p.Points
+		}
+	}
+
+	for _, t := range totals {
+		outlook(t.User).SeasonTotal += t.Total
+	}
+
+	weekLeader, seasonLeader := 0, 0
+	for i := range outlooks {
+		o := &outlooks[i]
+		o.WeekMax = o.WeekTotal + o.WeekRemaining
+		o.SeasonRemaining = o.WeekRemaining + futureMax
+		o.SeasonMax = o.SeasonTotal + o.SeasonRemaining
+
+		if o.WeekTotal > weekLeader {
+			weekLeader = o.WeekTotal
+		}
+		if o.SeasonTotal > seasonLeader {
+			seasonLeader = o.SeasonTotal
+		}
+	}
+
+	for i := range outlooks {
+		outlooks[i].WeekEliminated = outlooks[i].WeekMax < weekLeader
+		outlooks[i].SeasonEliminated = outlooks[i].SeasonMax < seasonLeader
+	}
+
+	return outlooks
+}
@@ -1,7 +1,5 @@
 package nflpickem
 
-import "errors"
-
 // PickRetriever is the interface implemented by types that can retrieve Pick information
 type PickRetriever interface {
 	Picks(year int, week int) (PickSet, error)
@@ -33,12 +31,32 @@ func (p Pick) Equal(other Pick) bool {
 	return p.Game.Equal(other.Game) && p.User.Equal(other.User)
 }
 
+// Correct returns whether or not the pick selected the winner of a finished game.
+func (p Pick) Correct() bool {
+	return p.Game.Final() && p.Selection != Team{} && p.Selection.Equal(p.Game.Winner())
+}
+
 const (
 	maxSevens = 1
 	maxFives  = 2
 	maxThrees = 5
 )
 
+// MaxWeekPoints returns the most points that can be earned in a week with the given
+// number of games, assuming every special point value is used and every pick is correct.
+func MaxWeekPoints(games int) int {
+	total := 0
+
+	for _, v := range []struct{ points, count int }{{7, maxSevens}, {5, maxFives}, {3, maxThrees}} {
+		for i := 0; i < v.count && games > 0; i++ {
+			total += v.points
+			games--
+		}
+	}
+
+	return total + games
+}
+
 // A PickSet represents the set of all picks for a user for a given Week
 type PickSet []Pick
 
@@ -68,8 +86,9 @@ func (picks PickSet) IsLegal() bool {
 }
 
 var (
-	ErrGameLocked       = errors.New("game has already started - pick locked")
-	ErrUnknownSelection = errors.New("selection does not match a game in the given pick set")
+	ErrGameLocked       = newError(KindLocked, "game_locked", "game has already started - pick locked")
+	ErrUnknownSelection = newError(KindInvalid, "unknown_selection", "selection does not match a game in the given pick set")
+	ErrIllegalPickSet   = newError(KindInvalid, "illegal_pick_set", "resulting pick set contains too many point values")
 )
 
 type PickFilterFunc func(p Pick) bool
@@ -0,0 +1,53 @@
+package nflpickem
+
+import "time"
+
+// NotificationEvent identifies a kind of notification a user may choose to receive.
+type NotificationEvent string
+
+const (
+	// PicksEvent notifies a user of the picks recorded after they make picks
+	PicksEvent NotificationEvent = "picks"
+)
+
+// NotificationEvents are the events a user may turn notifications on or off for. Account
+// notifications, such as password resets, are always sent.
+var NotificationEvents = []NotificationEvent{PicksEvent}
+
+// Profile is the information a user may change about themselves.
+//
+// PendingEmail is the address the user asked to change their e-mail to, which takes effect
+// once they follow the link sent to it. TimeZone is an IANA time zone name used to display
+// times, or empty to use the browser's time zone.
+type Profile struct {
+	FirstName     string                     `json:"firstName"`
+	LastName      string                     `json:"lastName"`
+	Email         string                     `json:"email"`
+	PendingEmail  string                     `json:"pendingEmail"`
+	TimeZone      string                     `json:"timeZone"`
+	Notifications map[NotificationEvent]bool `json:"notifications"`
+}
+
+// Notifies returns whether or not the user wants to be notified of the given event. Users
+// are notified of events they haven't expressed a preference for.
+func (p Profile) Notifies(event NotificationEvent) bool {
+	enabled, ok := p.Notifications[event]
+	return !ok || enabled
+}
+
+var (
+	ErrUnknownNotificationEvent = newError(KindInvalid, "unknown_notification_event", "unknown notification event")
+	ErrInvalidTimeZone          = newError(KindInvalid, "invalid_time_zone", "invalid time zone")
+	ErrInvalidEmailToken        = newError(KindInvalid, "invalid_email_token", "invalid or expired e-mail verification token")
+)
+
+// ProfileManager is the interface implemented by types that can store user profiles.
+//
+// A new e-mail address must be verified before it replaces the old one, by confirming the
+// token created for it.
+type ProfileManager interface {
+	Profile(username string, t time.Time) (Profile, error)
+	UpdateProfile(username string, p Profile) error
+	RequestEmailChange(username string, email string, expires time.Time) (token string, err error)
+	ConfirmEmailChange(token string, t time.Time) error
+}
@@ -0,0 +1,24 @@
+package nflpickem
+
+import "time"
+
+// Session is a user's login to the pool, identified by a secret token held by the client.
+//
+// User always reflects the current state of the user in the data source, so changes such
+// as revoking administrator privileges take effect immediately.
+type Session struct {
+	User     User      `json:"user"`
+	Created  time.Time `json:"created"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+var ErrInvalidSession = newError(KindBadCredentials, "invalid_session", "invalid session")
+
+// SessionManager is the interface implemented by types that can store login sessions.
+type SessionManager interface {
+	CreateSession(username string, t time.Time) (token string, err error)
+	Session(token string) (Session, error)
+	TouchSession(token string, t time.Time) error
+	DeleteSession(token string) error
+	DeleteUserSessions(username string) error
+}
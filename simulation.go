@@ -0,0 +1,127 @@
+package nflpickem
+
+import (
+	"math/rand"
+	"time"
+)
+
+// OddsModel names a strategy for estimating the outcome of a game that hasn't been played.
+type OddsModel string
+
+const (
+	// CoinFlip gives both teams an equal chance of winning every game.
+	CoinFlip OddsModel = "coin"
+	// TeamRecord favors the team with the better record in the season so far.
+	TeamRecord OddsModel = "record"
+)
+
+var ErrUnknownOddsModel = newError(KindInvalid, "unknown_odds_model", "unknown odds model")
+
+// OddsFunc returns the probability that the home team wins the given game.
+type OddsFunc func(g Game) float64
+
+// WinProbability is the chance of a user finishing the week with the most points.
+type WinProbability struct {
+	User        User    `json:"user"`
+	Probability float64 `json:"probability"`
+}
+
+// WinSimulator is the interface implemented by types that can estimate the chance of each
+// user winning a week by simulating the games that haven't finished yet. Only the picks for
+// games that have started as of time t are known to the simulation, so that it doesn't
+// reveal picks that are still hidden.
+type WinSimulator interface {
+	SimulateWeek(t time.Time, year int, week int, model OddsModel, trials int, seed int64) ([]WinProbability, error)
+}
+
+// Simulate plays out the unfinished games of the given picks trials times, choosing
+// winners with odds and r, and returns how often each user finished first. A week that
+// ends in a tie credits each of the leaders equally.
+func Simulate(picks PickSet, odds OddsFunc, trials int, r *rand.Rand) []WinProbability {
+	probabilities := make([]WinProbability, 0)
+	index := make(map[string]int)
+	earned := make([]int, 0)
+
+	// Separate the points that are already decided from the games still to be played
+	type pending struct {
+		game  Game
+		picks []Pick
+	}
+	games := make([]pending, 0)
+	gameIndex := make(map[Game]int)
+
+	for _, p := range picks {
+		i, ok := index[p.User.Email]
+		if !ok {
+			i = len(probabilities)
+			index[p.User.Email] = i
+			probabilities = append(probabilities, WinProbability{User: p.User})
+			earned = append(earned, 0)
+		}
+
+		if p.Game.Final() {
+			if p.Correct() {
+				earned[i] += p.Points
+			}
+			continue
+		}
+
+		g, ok := gameIndex[p.Game]
+		if !ok {
+			g = len(games)
+			gameIndex[p.Game] = g
+			games = append(games, pending{game: p.Game})
+		}
+		games[g].picks = append(games[g].picks, p)
+	}
+
+	if trials <= 0 || len(probabilities) == 0 {
+		return probabilities
+	}
+
+	homeOdds := make([]float64, len(games))
+	for i, g := range games {
+		homeOdds[i] = odds(g.game)
+	}
+
+	wins := make([]float64, len(probabilities))
+	totals := make([]int, len(probabilities))
+
+	for t := 0; t < trials; t++ {
+		copy(totals, earned)
+
+		for i, g := range games {
+			winner := g.game.Away
+			if r.Float64() < homeOdds[i] {
+				winner = g.game.Home
+			}
+
+			for _, p := range g.picks {
+				if p.Selection.Equal(winner) {
+					totals[index[p.User.Email]] += p.Points
+				}
+			}
+		}
+
+		best, leaders := -1, 0
+		for _, total := range totals {
+			if total > best {
+				best, leaders = total, 1
+			} else if total == best {
+				leaders++
+			}
+		}
+
+		for i, total := range totals {
+			if total == best {
+				wins[i] += 1 / float64(leaders)
+			}
+		}
+	}
+
+	for i := range probabilities {
+		probabilities[i].Probability = wins[i] / float64(trials)
+	}
+
+	return probabilities
+}
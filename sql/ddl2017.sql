@@ -11,10 +11,90 @@ CREATE TABLE IF NOT EXISTS users (
     last_name text NOT NULL,
     email text NOT NULL UNIQUE,
     admin boolean NOT NULL DEFAULT FALSE,
+    disabled boolean NOT NULL DEFAULT FALSE,
+    pending boolean NOT NULL DEFAULT FALSE,
+    time_zone text NOT NULL DEFAULT '',
     last_login timestamp,
     password text NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS password_resets (
+    id integer PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    token_hash text NOT NULL UNIQUE,
+    expires integer NOT NULL,
+    used boolean NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id integer PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    token_hash text NOT NULL UNIQUE,
+    created integer NOT NULL,
+    last_seen integer NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS lockouts (
+    id integer PRIMARY KEY,
+    kind text NOT NULL,
+    key text NOT NULL,
+    failures integer NOT NULL DEFAULT 0,
+    last_failure integer NOT NULL,
+    locked_until integer NOT NULL DEFAULT 0,
+    UNIQUE(kind, key)
+);
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id integer PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    scope text NOT NULL,
+    token_hash text NOT NULL UNIQUE,
+    created integer NOT NULL,
+    last_used integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS totp (
+    id integer PRIMARY KEY,
+    user_id integer UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    secret text NOT NULL,
+    enabled boolean NOT NULL DEFAULT FALSE,
+    last_step integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id integer PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    code_hash text NOT NULL,
+    used boolean NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS invites (
+    id integer PRIMARY KEY,
+    code_hash text NOT NULL UNIQUE,
+    created integer NOT NULL,
+    expires integer NOT NULL,
+    max_uses integer NOT NULL DEFAULT 1,
+    uses integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id integer PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    event text NOT NULL,
+    enabled boolean NOT NULL DEFAULT TRUE,
+    UNIQUE(user_id, event)
+);
+
+CREATE TABLE IF NOT EXISTS email_changes (
+    id integer PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    new_email text NOT NULL,
+    token_hash text NOT NULL UNIQUE,
+    expires integer NOT NULL,
+    used boolean NOT NULL DEFAULT FALSE
+);
+
 CREATE TABLE IF NOT EXISTS teams (
     id integer PRIMARY KEY,
     city varchar(64) NOT NULL,
@@ -66,6 +146,63 @@ CREATE TABLE IF NOT EXISTS statistics (
     lowest boolean
 );
 
+-- Responses are cached until the data they are built from changes
+CREATE TABLE IF NOT EXISTS changes (
+    id integer PRIMARY KEY CHECK (id = 1),
+    counter integer NOT NULL,
+    modified integer NOT NULL
+);
+
+INSERT OR IGNORE INTO changes(id, counter, modified) VALUES(1, 0, strftime('%s', 'now'));
+
+CREATE TRIGGER IF NOT EXISTS teams_insert AFTER INSERT ON teams BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS teams_update AFTER UPDATE ON teams BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS teams_delete AFTER DELETE ON teams BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS games_insert AFTER INSERT ON games BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS games_update AFTER UPDATE ON games BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS games_delete AFTER DELETE ON games BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS picks_insert AFTER INSERT ON picks BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS picks_update AFTER UPDATE ON picks BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS picks_delete AFTER DELETE ON picks BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS users_insert AFTER INSERT ON users BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS users_update AFTER UPDATE ON users BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
+CREATE TRIGGER IF NOT EXISTS users_delete AFTER DELETE ON users BEGIN
+    UPDATE changes SET counter = counter + 1, modified = strftime('%s', 'now');
+END;
+
 INSERT INTO teams(city, nickname, stadium, abbreviation) VALUES('Buffalo', 'Bills', 'Ralph Wilson Stadium', 'BUF');
 INSERT INTO teams(city, nickname, stadium, abbreviation) VALUES('Miami', 'Dolphins', 'Sun Life Stadium', 'MIA');
 INSERT INTO teams(city, nickname, stadium, abbreviation) VALUES('New England', 'Patriots', 'Gilette Stadium', 'NE');
@@ -0,0 +1,123 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// CreateAPIToken creates a new API token for the given user, returning the token itself.
+// Only a hash of the token is stored.
+func (db Datastore) CreateAPIToken(username string, name string, scope nflpickem.TokenScope, t time.Time) (string, error) {
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := db.Exec(`INSERT INTO api_tokens(user_id, name, scope, token_hash, created)
+		SELECT id, ?2, ?3, ?4, ?5 FROM users WHERE email = ?1`, username, name, string(scope), hash, t.Unix())
+	if err != nil {
+		return "", err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+
+	if n == 0 {
+		return "", nflpickem.ErrUnknownUser
+	}
+
+	return token, nil
+}
+
+// APIToken returns the API token identified by the given token, along with the current
+// information for its user.
+func (db Datastore) APIToken(token string) (nflpickem.APIToken, error) {
+	var a nflpickem.APIToken
+	var created, lastUsed int64
+
+	row := db.QueryRow(`SELECT api_tokens.id, api_tokens.name, api_tokens.scope, api_tokens.created, api_tokens.last_used,
+			users.first_name, users.last_name, users.email, users.admin, users.disabled
+		FROM api_tokens
+		JOIN users ON api_tokens.user_id = users.id
+		WHERE api_tokens.token_hash = ?1`, hashToken(token))
+	err := row.Scan(&a.ID, &a.Name, &a.Scope, &created, &lastUsed, &a.User.FirstName, &a.User.LastName, &a.User.Email, &a.User.Admin, &a.User.Disabled)
+	if err == sql.ErrNoRows {
+		return nflpickem.APIToken{}, nflpickem.ErrInvalidAPIToken
+	} else if err != nil {
+		return nflpickem.APIToken{}, err
+	}
+
+	a.Created = time.Unix(created, 0)
+	a.LastUsed = lastUsedTime(lastUsed)
+
+	return a, nil
+}
+
+// APITokens returns every API token belonging to the given user, newest first.
+func (db Datastore) APITokens(username string) ([]nflpickem.APIToken, error) {
+	rows, err := db.Query(`SELECT api_tokens.id, api_tokens.name, api_tokens.scope, api_tokens.created, api_tokens.last_used
+		FROM api_tokens
+		JOIN users ON api_tokens.user_id = users.id
+		WHERE users.email = ?1
+		ORDER BY api_tokens.created DESC, api_tokens.id DESC`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]nflpickem.APIToken, 0)
+
+	for rows.Next() {
+		var tmp nflpickem.APIToken
+		var created, lastUsed int64
+		err := rows.Scan(&tmp.ID, &tmp.Name, &tmp.Scope, &created, &lastUsed)
+		if err != nil {
+			return nil, err
+		}
+
+		tmp.Created = time.Unix(created, 0)
+		tmp.LastUsed = lastUsedTime(lastUsed)
+
+		tokens = append(tokens, tmp)
+	}
+
+	return tokens, rows.Err()
+}
+
+// TouchAPIToken records that the API token with the given ID was used at time t.
+func (db Datastore) TouchAPIToken(id int64, t time.Time) error {
+	_, err := db.Exec("UPDATE api_tokens SET last_used = ?1 WHERE id = ?2", t.Unix(), id)
+	return err
+}
+
+// RevokeAPIToken deletes the API token with the given ID, provided it belongs to the given user.
+func (db Datastore) RevokeAPIToken(username string, id int64) error {
+	res, err := db.Exec("DELETE FROM api_tokens WHERE id = ?1 AND user_id = (SELECT id FROM users WHERE email = ?2)", id, username)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return nflpickem.ErrUnknownAPIToken
+	}
+
+	return nil
+}
+
+// lastUsedTime converts a stored last use into a time, where 0 means never used.
+func lastUsedTime(lastUsed int64) time.Time {
+	if lastUsed == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(lastUsed, 0)
+}
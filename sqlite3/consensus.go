@@ -0,0 +1,18 @@
+package sqlite3
+
+import (
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// Consensus returns the pool's pick distribution for every game of the given week of the
+// NFL season that has already started based on the provided date.
+func (db Datastore) Consensus(t time.Time, year int, week int) ([]nflpickem.Consensus, error) {
+	results, err := db.Results(t, year, week)
+	if err != nil {
+		return nil, err
+	}
+
+	return nflpickem.NewConsensus(results), nil
+}
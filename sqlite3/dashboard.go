@@ -0,0 +1,64 @@
+package sqlite3
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// Dashboard returns the given user's dashboard for a week of the NFL season as of t. Every
+// part of it is read in a single transaction, so that it is consistent even while scores
+// and picks are being updated.
+func (db Datastore) Dashboard(username string, t time.Time, year int, week int) (nflpickem.Dashboard, error) {
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nflpickem.Dashboard{}, err
+	}
+	defer tx.Rollback()
+
+	var d nflpickem.Dashboard
+
+	d.Current, err = currentWeek(tx, t)
+	if err != nil {
+		return nflpickem.Dashboard{}, err
+	}
+
+	d.Year, d.Week = year, week
+	if year == 0 && week == 0 {
+		d.Year, d.Week = d.Current.Year, d.Current.Week
+	}
+
+	g, err := games(tx, d.Year, d.Week, d.Week)
+	if err != nil {
+		return nflpickem.Dashboard{}, err
+	}
+
+	d.Games = make([]nflpickem.GameState, 0, len(g))
+	for _, game := range g {
+		d.Games = append(d.Games, nflpickem.GameState{Game: game, Locked: !game.Date.After(t)})
+	}
+
+	d.Picks, err = userPicks(tx, username, d.Year, d.Week)
+	if err != nil {
+		return nflpickem.Dashboard{}, err
+	}
+
+	d.Results, err = results(tx, t, d.Year, d.Week)
+	if err != nil {
+		return nflpickem.Dashboard{}, err
+	}
+
+	d.WeekTotals, err = weekTotals(tx, "%", d.Year, d.Week, d.Week)
+	if err != nil {
+		return nflpickem.Dashboard{}, err
+	}
+
+	d.CumulativeTotals, err = weekTotals(tx, "%", d.Year, 1, d.Week)
+	if err != nil {
+		return nflpickem.Dashboard{}, err
+	}
+
+	return d, tx.Commit()
+}
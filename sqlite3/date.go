@@ -7,17 +7,18 @@ import (
 	"github.com/ameske/nfl-pickem"
 )
 
-const (
-	oneWeek      = time.Hour * 24 * 7
-	seasonLength = 17
-)
+const oneWeek = time.Hour * 24 * 7
 
 // CurrentWeek returns the current week of the season. A season starts on the
 // Tuesday before the first game. A new week starts every Tuesday. Given the
 // current time, we can calculate the current week of the season. A week set
 // to -1 means that we are in the offseason.
 func (db Datastore) CurrentWeek(t time.Time) (nflpickem.Week, error) {
-	start, err := db.currentSeasonStart(t)
+	return currentWeek(db.DB, t)
+}
+
+func currentWeek(q reader, t time.Time) (nflpickem.Week, error) {
+	start, err := currentSeasonStart(q, t)
 	if err != nil {
 		return nflpickem.Week{Year: -1, Week: -1}, err
 	}
@@ -26,18 +27,18 @@ func (db Datastore) CurrentWeek(t time.Time) (nflpickem.Week, error) {
 
 	week := int(d/oneWeek) + 1
 
-	if week > seasonLength {
+	if week > nflpickem.SeasonLength {
 		return nflpickem.Week{Year: start.Year(), Week: -1}, nil
 	}
 
 	return nflpickem.Week{Year: start.Year(), Week: week}, nil
 }
 
-func (db Datastore) currentSeasonStart(t time.Time) (start time.Time, err error) {
+func currentSeasonStart(q reader, t time.Time) (start time.Time, err error) {
 	now := t.Unix()
 
 	var s sql.NullInt64
-	row := db.QueryRow("SELECT MAX(year_start) FROM years WHERE year_start < ?1", now)
+	row := q.QueryRow("SELECT MAX(year_start) FROM years WHERE year_start < ?1", now)
 	err = row.Scan(&s)
 	if err != nil {
 		return time.Unix(0, 0), err
@@ -46,7 +47,7 @@ func (db Datastore) currentSeasonStart(t time.Time) (start time.Time, err error)
 	// Special case: if now + 7 is a different value then that means we're on the cusp of a new season. So pretend we are in week 1.
 	now2 := time.Date(t.Year(), t.Month(), t.Day()+7, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
 	var s2 sql.NullInt64
-	row = db.QueryRow("SELECT MAX(year_start) FROM years WHERE year_start < ?1", now2.Unix())
+	row = q.QueryRow("SELECT MAX(year_start) FROM years WHERE year_start < ?1", now2.Unix())
 	err = row.Scan(&s2)
 	if err != nil {
 		return time.Unix(0, 0), err
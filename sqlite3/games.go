@@ -8,15 +8,15 @@ import (
 
 // WeekGames returns the games for only the specified week from the datastore.
 func (db Datastore) WeekGames(year int, week int) ([]nflpickem.Game, error) {
-	return db.games(year, week, week)
+	return games(db.DB, year, week, week)
 }
 
 // CumulativeGames returns games up to the specified week from the datastore.
 func (db Datastore) CumulativeGames(year int, week int) ([]nflpickem.Game, error) {
-	return db.games(year, 1, week)
+	return games(db.DB, year, 1, week)
 }
 
-func (db Datastore) games(year int, minWeek int, maxWeek int) ([]nflpickem.Game, error) {
+func games(q reader, year int, minWeek int, maxWeek int) ([]nflpickem.Game, error) {
 	sql := `SELECT years.year, weeks.week, games.date, home.city, home.nickname, away.city, away.nickname, games.home_score, games.away_score
 	    FROM games
 	    JOIN teams AS home ON games.home_id = home.id
@@ -25,7 +25,7 @@ func (db Datastore) games(year int, minWeek int, maxWeek int) ([]nflpickem.Game,
 	    JOIN years ON weeks.year_id = years.id
 	    WHERE years.year = ?1 AND weeks.week >= ?2 AND weeks.week <= ?3`
 
-	rows, err := db.Query(sql, year, minWeek, maxWeek)
+	rows, err := q.Query(sql, year, minWeek, maxWeek)
 	if err != nil {
 		return nil, err
 	}
@@ -54,24 +54,30 @@ func (db Datastore) games(year int, minWeek int, maxWeek int) ([]nflpickem.Game,
 // year, week, and home team, which uniquely identifies any game.
 func (db Datastore) UpdateGame(week int, year int, homeTeam string, homeScore int, awayScore int) error {
 	// sqlite3 makes this hard on us by not allowing JOIN in UPDATE
-	// so we have to do this in a couple of steps
-	sql := `SELECT games.id FROM games
-		JOIN weeks ON games.week_id = weeks.id
-		JOIN years ON weeks.year_id = years.id
-		JOIN teams ON games.home_id = teams.id
-		WHERE weeks.week = ?1 AND years.year = ?2 AND teams.nickname = ?3`
-
-	var gameId int64
-	err := db.QueryRow(sql, week, year, homeTeam).Scan(&gameId)
+	// so we have to find the game in a subquery
+	sql := `UPDATE games
+		SET home_score = ?4, away_score = ?5
+		WHERE id = (SELECT games.id FROM games
+			JOIN weeks ON games.week_id = weeks.id
+			JOIN years ON weeks.year_id = years.id
+			JOIN teams ON games.home_id = teams.id
+			WHERE weeks.week = ?1 AND years.year = ?2 AND teams.nickname = ?3)`
+
+	res, err := db.Exec(sql, week, year, homeTeam, homeScore, awayScore)
 	if err != nil {
 		return err
 	}
 
-	_, err = db.Exec(`UPDATE games
-			  SET home_score = ?2, away_score = ?3
-			  WHERE id = ?1`, gameId, homeScore, awayScore)
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-	return err
+	if updated == 0 {
+		return nflpickem.ErrUnknownGame
+	}
+
+	return nil
 }
 
 // AddGame adds the given game to the datastore.
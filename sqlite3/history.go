@@ -0,0 +1,67 @@
+package sqlite3
+
+import (
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// History returns every user's performance across all seasons in the datastore.
+func (db Datastore) History() ([]nflpickem.History, error) {
+	picks, err := db.allPicks()
+	if err != nil {
+		return nil, err
+	}
+
+	return nflpickem.NewHistory(picks), nil
+}
+
+// Records returns the all-time records of the pool across all seasons in the datastore.
+func (db Datastore) Records() (nflpickem.Records, error) {
+	picks, err := db.allPicks()
+	if err != nil {
+		return nflpickem.Records{}, err
+	}
+
+	return nflpickem.NewRecords(picks), nil
+}
+
+// allPicks returns every pick of every season in the datastore, ordered by kickoff.
+func (db Datastore) allPicks() (nflpickem.PickSet, error) {
+	sql := `SELECT years.year, weeks.week, home.city, home.nickname, away.city, away.nickname, games.date, games.home_score, games.away_score, IFNULL(selection.city, ''), IFNULL(selection.nickname, ''), picks.points, users.first_name, users.last_name, users.email
+		FROM picks
+		JOIN games ON picks.game_id = games.id
+		JOIN teams AS home ON games.home_id = home.id
+		JOIN teams AS away ON games.away_id = away.id
+		LEFT JOIN teams AS selection ON picks.selection = selection.id
+		JOIN weeks ON games.week_id = weeks.id
+		JOIN years ON weeks.year_id = years.id
+		JOIN users ON picks.user_id = users.id
+		ORDER BY games.date ASC, games.id ASC, users.email ASC`
+
+	rows, err := db.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	picks := make(nflpickem.PickSet, 0)
+
+	for rows.Next() {
+		var tmp nflpickem.Pick
+		var d int64
+		err := rows.Scan(&tmp.Game.Year, &tmp.Game.Week, &tmp.Game.Home.City, &tmp.Game.Home.Nickname, &tmp.Game.Away.City, &tmp.Game.Away.Nickname, &d, &tmp.Game.HomeScore, &tmp.Game.AwayScore,
+			&tmp.Selection.City, &tmp.Selection.Nickname,
+			&tmp.Points,
+			&tmp.User.FirstName, &tmp.User.LastName, &tmp.User.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		tmp.Game.Date = time.Unix(d, 0)
+
+		picks = append(picks, tmp)
+	}
+
+	return picks, rows.Err()
+}
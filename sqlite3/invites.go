@@ -0,0 +1,220 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CreateInvite creates an invite code that can register up to maxUses users until expires.
+// Only a hash of the code is stored.
+func (db Datastore) CreateInvite(t time.Time, expires time.Time, maxUses int) (string, error) {
+	code, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec("INSERT INTO invites(code_hash, created, expires, max_uses) VALUES(?1, ?2, ?3, ?4)", hash, t.Unix(), expires.Unix(), maxUses)
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// Invites returns every invite, newest first.
+func (db Datastore) Invites() ([]nflpickem.Invite, error) {
+	rows, err := db.Query("SELECT id, created, expires, max_uses, uses FROM invites ORDER BY created DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := make([]nflpickem.Invite, 0)
+
+	for rows.Next() {
+		var tmp nflpickem.Invite
+		var created, expires int64
+		err := rows.Scan(&tmp.ID, &created, &expires, &tmp.MaxUses, &tmp.Uses)
+		if err != nil {
+			return nil, err
+		}
+
+		tmp.Created = time.Unix(created, 0)
+		tmp.Expires = time.Unix(expires, 0)
+
+		invites = append(invites, tmp)
+	}
+
+	return invites, rows.Err()
+}
+
+// RevokeInvite deletes the invite with the given ID, so that it can't be used to register.
+func (db Datastore) RevokeInvite(id int64) error {
+	res, err := db.Exec("DELETE FROM invites WHERE id = ?1", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return nflpickem.ErrUnknownInvite
+	}
+
+	return nil
+}
+
+// Register uses the invite code at time t to add a new user. A pending user can't log in
+// until they are approved; otherwise, the user is activated immediately, along with their
+// picks for the rest of the season.
+func (db Datastore) Register(code string, t time.Time, first string, last string, email string, password string, pending bool) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE invites SET uses = uses + 1 WHERE code_hash = ?1 AND uses < max_uses AND expires > ?2", hashToken(code), t.Unix())
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return nflpickem.ErrInvalidInvite
+	}
+
+	var exists bool
+	err = tx.QueryRow("SELECT COUNT(*) > 0 FROM users WHERE email = ?1", email).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nflpickem.ErrUserExists
+	}
+
+	_, err = tx.Exec("INSERT INTO users(first_name, last_name, email, password, pending) VALUES(?1, ?2, ?3, ?4, ?5)", first, last, email, hash, pending)
+	if err != nil {
+		return err
+	}
+
+	if !pending {
+		err = createRemainingPicks(tx, email, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// PendingUsers returns every user awaiting approval.
+func (db Datastore) PendingUsers() ([]nflpickem.User, error) {
+	users, err := db.Users()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]nflpickem.User, 0)
+	for _, u := range users {
+		if u.Pending {
+			pending = append(pending, u)
+		}
+	}
+
+	return pending, nil
+}
+
+// ApproveUser activates a user awaiting approval at time t.
+func (db Datastore) ApproveUser(username string, t time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE users SET pending = 0 WHERE email = ?1 AND pending = 1", username)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return nflpickem.ErrUnknownUser
+	}
+
+	err = createRemainingPicks(tx, username, t)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RejectUser removes a user awaiting approval.
+func (db Datastore) RejectUser(username string) error {
+	res, err := db.Exec("DELETE FROM users WHERE email = ?1 AND pending = 1", username)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return nflpickem.ErrUnknownUser
+	}
+
+	return nil
+}
+
+// createRemainingPicks creates the user's picks for the current week, and every week after
+// it, of the season in progress at time t. Nothing is created during the offseason.
+func createRemainingPicks(q writer, username string, t time.Time) error {
+	current, err := currentWeek(q, t)
+	if err != nil {
+		return err
+	}
+
+	if current.Week <= 0 {
+		return nil
+	}
+
+	var last sql.NullInt64
+	row := q.QueryRow("SELECT MAX(weeks.week) FROM weeks JOIN years ON weeks.year_id = years.id WHERE years.year = ?1", current.Year)
+	err = row.Scan(&last)
+	if err != nil {
+		return err
+	}
+
+	for week := current.Week; week <= int(last.Int64); week++ {
+		err = createPicks(q, username, current.Year, week)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// Lockout returns the recorded login failures for the given account or IP address. A
+// Lockout without any failures is returned if none have been recorded.
+func (db Datastore) Lockout(kind nflpickem.LockoutKind, key string) (nflpickem.Lockout, error) {
+	l := nflpickem.Lockout{Kind: kind, Key: key}
+	var lastFailure, lockedUntil int64
+
+	row := db.QueryRow("SELECT failures, last_failure, locked_until FROM lockouts WHERE kind = ?1 AND key = ?2", string(kind), key)
+	err := row.Scan(&l.Failures, &lastFailure, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return l, nil
+	} else if err != nil {
+		return l, err
+	}
+
+	l.LastFailure = time.Unix(lastFailure, 0)
+	l.LockedUntil = time.Unix(lockedUntil, 0)
+
+	return l, nil
+}
+
+// SaveLockout stores the given lockout, replacing any previously stored for the same key.
+func (db Datastore) SaveLockout(l nflpickem.Lockout) error {
+	_, err := db.Exec(`INSERT OR REPLACE INTO lockouts(kind, key, failures, last_failure, locked_until)
+		VALUES(?1, ?2, ?3, ?4, ?5)`, string(l.Kind), l.Key, l.Failures, l.LastFailure.Unix(), l.LockedUntil.Unix())
+
+	return err
+}
+
+// ClearLockout forgets the recorded login failures for the given account or IP address.
+func (db Datastore) ClearLockout(kind nflpickem.LockoutKind, key string) error {
+	_, err := db.Exec("DELETE FROM lockouts WHERE kind = ?1 AND key = ?2", string(kind), key)
+	return err
+}
+
+// Lockouts returns every account and IP address with recorded login failures, most recent first.
+func (db Datastore) Lockouts() ([]nflpickem.Lockout, error) {
+	rows, err := db.Query("SELECT kind, key, failures, last_failure, locked_until FROM lockouts ORDER BY last_failure DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lockouts := make([]nflpickem.Lockout, 0)
+
+	for rows.Next() {
+		var tmp nflpickem.Lockout
+		var lastFailure, lockedUntil int64
+		err := rows.Scan(&tmp.Kind, &tmp.Key, &tmp.Failures, &lastFailure, &lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+
+		tmp.LastFailure = time.Unix(lastFailure, 0)
+		tmp.LockedUntil = time.Unix(lockedUntil, 0)
+
+		lockouts = append(lockouts, tmp)
+	}
+
+	return lockouts, rows.Err()
+}
@@ -11,6 +11,20 @@ type Datastore struct {
 	*sql.DB
 }
 
+// reader is implemented by both *sql.DB and *sql.Tx, so that queries can be shared by
+// methods that read inside a transaction and methods that don't.
+type reader interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// writer is implemented by both *sql.DB and *sql.Tx, so that changes can be shared by
+// methods that write inside a transaction and methods that don't.
+type writer interface {
+	reader
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 // NewDatastore connects to a sqlite3 database storing NFL pickem data.
 func NewDatastore(path string) (*Datastore, error) {
 	db, err := sql.Open("sqlite3", path)
@@ -0,0 +1,34 @@
+package sqlite3
+
+import "github.com/ameske/nfl-pickem"
+
+// Outlook returns every user's earned points, points still at stake, and elimination
+// status for the given week of the NFL season.
+func (db Datastore) Outlook(year int, week int) ([]nflpickem.Outlook, error) {
+	picks, err := db.Picks(year, week)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := db.CumulativeWeekTotals(year, week)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, err := games(db.DB, year, week+1, nflpickem.SeasonLength)
+	if err != nil {
+		return nil, err
+	}
+
+	weekGames := make(map[int]int)
+	for _, g := range remaining {
+		weekGames[g.Week]++
+	}
+
+	futureMax := 0
+	for _, n := range weekGames {
+		futureMax += nflpickem.MaxWeekPoints(n)
+	}
+
+	return nflpickem.NewOutlooks(year, week, picks, totals, futureMax), nil
+}
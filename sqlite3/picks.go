@@ -9,6 +9,10 @@ import (
 
 // SelectedPicks returns the user's selected picks for the given week of the requested NFL season.
 func (db Datastore) SelectedPicks(username string, year int, week int) (nflpickem.PickSet, error) {
+	return selectedPicks(db.DB, username, year, week)
+}
+
+func selectedPicks(q reader, username string, year int, week int) (nflpickem.PickSet, error) {
 	sql := `SELECT years.year, weeks.week, home.city, home.nickname, away.city, away.nickname, games.date, games.home_score, games.away_score, selection.city, selection.nickname, picks.points, users.first_name, users.last_name, users.email
 		FROM picks
 		JOIN games ON picks.game_id = games.id
@@ -20,7 +24,7 @@ func (db Datastore) SelectedPicks(username string, year int, week int) (nflpicke
 		JOIN users ON picks.user_id = users.id
 		WHERE picks.selection IS NOT NULL AND users.email LIKE ?1 AND years.year = ?2 AND weeks.week = ?3`
 
-	rows, err := db.Query(sql, username, year, week)
+	rows, err := q.Query(sql, username, year, week)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +53,10 @@ func (db Datastore) SelectedPicks(username string, year int, week int) (nflpicke
 
 // SelectedPicks returns the user's selected picks for the given week of the requested NFL season.
 func (db Datastore) UnselectedPicks(username string, year int, week int) (nflpickem.PickSet, error) {
+	return unselectedPicks(db.DB, username, year, week)
+}
+
+func unselectedPicks(q reader, username string, year int, week int) (nflpickem.PickSet, error) {
 	sql := `SELECT years.year, weeks.week, home.city, home.nickname, away.city, away.nickname, games.date, games.home_score, games.away_score, users.first_name, users.last_name, users.email
 		FROM picks
 		JOIN games ON picks.game_id = games.id
@@ -59,7 +67,7 @@ func (db Datastore) UnselectedPicks(username string, year int, week int) (nflpic
 		JOIN users ON picks.user_id = users.id
 		WHERE picks.selection IS NULL AND users.email LIKE ?1 AND years.year = ?2 AND weeks.week = ?3`
 
-	rows, err := db.Query(sql, username, year, week)
+	rows, err := q.Query(sql, username, year, week)
 	if err != nil {
 		return nil, err
 	}
@@ -101,12 +109,16 @@ func (db Datastore) Picks(year int, week int) (nflpickem.PickSet, error) {
 
 // Picks returns the given user's picks for the given week of the requested NFL season.
 func (db Datastore) UserPicks(username string, year int, week int) (nflpickem.PickSet, error) {
-	selected, err := db.SelectedPicks(username, year, week)
+	return userPicks(db.DB, username, year, week)
+}
+
+func userPicks(q reader, username string, year int, week int) (nflpickem.PickSet, error) {
+	selected, err := selectedPicks(q, username, year, week)
 	if err != nil {
 		return nil, err
 	}
 
-	unselected, err := db.UnselectedPicks(username, year, week)
+	unselected, err := unselectedPicks(q, username, year, week)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +143,11 @@ func (db Datastore) MakePicks(picks nflpickem.PickSet) error {
 }
 
 func (db Datastore) CreatePicks(username string, year int, week int) error {
-	games, err := gameIds(db, year, week)
+	return createPicks(db.DB, username, year, week)
+}
+
+func createPicks(q writer, username string, year int, week int) error {
+	games, err := gameIds(q, year, week)
 	if err != nil {
 		return err
 	}
@@ -139,7 +155,7 @@ func (db Datastore) CreatePicks(username string, year int, week int) error {
 	sql := `INSERT INTO picks(user_id, game_id) VALUES((SELECT id FROM users WHERE email = ?1), ?2)`
 
 	for _, gid := range games {
-		_, err = db.Exec(sql, username, gid)
+		_, err = q.Exec(sql, username, gid)
 		if err != nil {
 			return err
 		}
@@ -160,7 +176,7 @@ func updatePick(db Datastore, pick nflpickem.Pick) error {
 	return err
 }
 
-func gameIds(db Datastore, year int, week int) ([]int, error) {
+func gameIds(q reader, year int, week int) ([]int, error) {
 	sql := `SELECT id
 		FROM games
 		WHERE week_id = (SELECT weeks.id
@@ -168,7 +184,7 @@ func gameIds(db Datastore, year int, week int) ([]int, error) {
 				  JOIN years ON weeks.year_id = years.id
 				  WHERE years.year = ?1 AND weeks.week = ?2)`
 
-	rows, err := db.Query(sql, year, week)
+	rows, err := q.Query(sql, year, week)
 	if err != nil {
 		return nil, err
 	}
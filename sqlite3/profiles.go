@@ -0,0 +1,170 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// Profile returns the given user's profile, including any e-mail change still awaiting
+// verification as of t.
+func (db Datastore) Profile(username string, t time.Time) (nflpickem.Profile, error) {
+	var p nflpickem.Profile
+	var userID int64
+
+	row := db.QueryRow("SELECT id, first_name, last_name, email, time_zone FROM users WHERE email = ?1", username)
+	err := row.Scan(&userID, &p.FirstName, &p.LastName, &p.Email, &p.TimeZone)
+	if err == sql.ErrNoRows {
+		return nflpickem.Profile{}, nflpickem.ErrUnknownUser
+	} else if err != nil {
+		return nflpickem.Profile{}, err
+	}
+
+	row = db.QueryRow("SELECT new_email FROM email_changes WHERE user_id = ?1 AND used = 0 AND expires > ?2 ORDER BY id DESC LIMIT 1", userID, t.Unix())
+	err = row.Scan(&p.PendingEmail)
+	if err != nil && err != sql.ErrNoRows {
+		return nflpickem.Profile{}, err
+	}
+
+	rows, err := db.Query("SELECT event, enabled FROM notification_preferences WHERE user_id = ?1", userID)
+	if err != nil {
+		return nflpickem.Profile{}, err
+	}
+	defer rows.Close()
+
+	p.Notifications = make(map[nflpickem.NotificationEvent]bool)
+
+	for rows.Next() {
+		var event nflpickem.NotificationEvent
+		var enabled bool
+		err := rows.Scan(&event, &enabled)
+		if err != nil {
+			return nflpickem.Profile{}, err
+		}
+
+		p.Notifications[event] = enabled
+	}
+
+	return p, rows.Err()
+}
+
+// UpdateProfile stores the user's names, time zone, and notification preferences. The
+// e-mail address can only be changed through RequestEmailChange.
+func (db Datastore) UpdateProfile(username string, p nflpickem.Profile) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	err = tx.QueryRow("SELECT id FROM users WHERE email = ?1", username).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nflpickem.ErrUnknownUser
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE users SET first_name = ?1, last_name = ?2, time_zone = ?3 WHERE id = ?4", p.FirstName, p.LastName, p.TimeZone, userID)
+	if err != nil {
+		return err
+	}
+
+	for event, enabled := range p.Notifications {
+		_, err = tx.Exec("INSERT OR REPLACE INTO notification_preferences(user_id, event, enabled) VALUES(?1, ?2, ?3)", userID, string(event), enabled)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RequestEmailChange creates a token that can be confirmed until expires to change the
+// user's e-mail address. Any earlier request is replaced. Only a hash of the token is stored.
+func (db Datastore) RequestEmailChange(username string, email string, expires time.Time) (string, error) {
+	var userID int64
+	err := db.QueryRow("SELECT id FROM users WHERE email = ?1", username).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nflpickem.ErrUnknownUser
+	} else if err != nil {
+		return "", err
+	}
+
+	var taken bool
+	err = db.QueryRow("SELECT COUNT(*) > 0 FROM users WHERE email = ?1", email).Scan(&taken)
+	if err != nil {
+		return "", err
+	}
+
+	if taken {
+		return "", nflpickem.ErrUserExists
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM email_changes WHERE user_id = ?1 AND used = 0", userID)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec("INSERT INTO email_changes(user_id, new_email, token_hash, expires) VALUES(?1, ?2, ?3, ?4)", userID, email, hash, expires.Unix())
+	if err != nil {
+		return "", err
+	}
+
+	return token, tx.Commit()
+}
+
+// ConfirmEmailChange changes the e-mail address of the user the token was created for,
+// as long as the token hasn't expired or already been used as of t. The user stays logged
+// in, since sessions don't depend on the e-mail address.
+func (db Datastore) ConfirmEmailChange(token string, t time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var changeID, userID int64
+	var email string
+	row := tx.QueryRow("SELECT id, user_id, new_email FROM email_changes WHERE token_hash = ?1 AND used = 0 AND expires > ?2", hashToken(token), t.Unix())
+	err = row.Scan(&changeID, &userID, &email)
+	if err == sql.ErrNoRows {
+		return nflpickem.ErrInvalidEmailToken
+	} else if err != nil {
+		return err
+	}
+
+	var taken bool
+	err = tx.QueryRow("SELECT COUNT(*) > 0 FROM users WHERE email = ?1", email).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return nflpickem.ErrUserExists
+	}
+
+	_, err = tx.Exec("UPDATE email_changes SET used = 1 WHERE id = ?1", changeID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE users SET email = ?1 WHERE id = ?2", email, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
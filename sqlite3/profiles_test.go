@@ -0,0 +1,44 @@
+package sqlite3
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPendingEmailExpires(t *testing.T) {
+	db := newTestDatastore(t)
+
+	err := db.AddUser("Alice", "Smith", "alice@example.com", "password", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2017, time.September, 10, 13, 0, 0, 0, time.UTC)
+	expires := now.Add(24 * time.Hour)
+
+	_, err = db.RequestEmailChange("alice@example.com", "alice@example.org", expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	times := []struct {
+		name    string
+		t       time.Time
+		pending string
+	}{
+		{"before expiring", now, "alice@example.org"},
+		{"when expiring", expires, ""},
+		{"after expiring", expires.Add(time.Hour), ""},
+	}
+
+	for _, tt := range times {
+		p, err := db.Profile("alice@example.com", tt.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.PendingEmail != tt.pending {
+			t.Errorf("%s: pending e-mail is %q, expected %q", tt.name, p.PendingEmail, tt.pending)
+		}
+	}
+}
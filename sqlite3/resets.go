@@ -0,0 +1,75 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CreatePasswordReset creates a single-use token that can be redeemed until expires to
+// set a new password for the given user. Only a hash of the token is stored.
+func (db Datastore) CreatePasswordReset(username string, expires time.Time) (string, error) {
+	var userID int64
+	err := db.QueryRow("SELECT id FROM users WHERE email = ?1 AND disabled = 0", username).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nflpickem.ErrUnknownUser
+	} else if err != nil {
+		return "", err
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec("INSERT INTO password_resets(user_id, token_hash, expires) VALUES(?1, ?2, ?3)", userID, hash, expires.Unix())
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RedeemPasswordReset sets a new password for the user the token was created for, as long
+// as the token hasn't expired or already been used as of t.
+func (db Datastore) RedeemPasswordReset(token string, t time.Time, newPassword string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var resetID, userID int64
+	row := tx.QueryRow("SELECT id, user_id FROM password_resets WHERE token_hash = ?1 AND used = 0 AND expires > ?2", hashToken(token), t.Unix())
+	err = row.Scan(&resetID, &userID)
+	if err == sql.ErrNoRows {
+		return nflpickem.ErrInvalidResetToken
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE password_resets SET used = 1 WHERE id = ?1", resetID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE users SET password = ?1 WHERE id = ?2", string(hash), userID)
+	if err != nil {
+		return err
+	}
+
+	// Anybody who was logged in with the forgotten password is logged out
+	_, err = tx.Exec("DELETE FROM sessions WHERE user_id = ?1", userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
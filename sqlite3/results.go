@@ -9,6 +9,10 @@ import (
 // Results returns the set of picks for the given week of the NFL season that have already started
 // based on the provided date.
 func (db Datastore) Results(t time.Time, year int, week int) ([]nflpickem.Result, error) {
+	return results(db.DB, t, year, week)
+}
+
+func results(q reader, t time.Time, year int, week int) ([]nflpickem.Result, error) {
 	sql := `SELECT years.year, weeks.week, home.city, home.nickname, away.city, away.nickname, games.date, games.home_score, games.away_score, selection.city, selection.nickname, picks.points, users.first_name, users.last_name, users.email
 		FROM picks
 		JOIN games ON picks.game_id = games.id
@@ -20,7 +24,7 @@ func (db Datastore) Results(t time.Time, year int, week int) ([]nflpickem.Result
 		JOIN users ON picks.user_id = users.id
 		WHERE picks.selection IS NOT NULL AND games.date < ?1 AND years.year = ?2 AND weeks.week = ?3 ORDER BY games.date ASC, games.id ASC, users.email ASC`
 
-	rows, err := db.Query(sql, t.Unix(), year, week)
+	rows, err := q.Query(sql, t.Unix(), year, week)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,75 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// CreateSession starts a new session for the given user at time t, returning the token
+// that identifies it. Only a hash of the token is stored.
+func (db Datastore) CreateSession(username string, t time.Time) (string, error) {
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := db.Exec(`INSERT INTO sessions(user_id, token_hash, created, last_seen)
+		SELECT id, ?2, ?3, ?3 FROM users WHERE email = ?1`, username, hash, t.Unix())
+	if err != nil {
+		return "", err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+
+	if n == 0 {
+		return "", nflpickem.ErrUnknownUser
+	}
+
+	return token, nil
+}
+
+// Session returns the session identified by the given token, along with the current
+// information for its user.
+func (db Datastore) Session(token string) (nflpickem.Session, error) {
+	var s nflpickem.Session
+	var created, lastSeen int64
+
+	row := db.QueryRow(`SELECT users.first_name, users.last_name, users.email, users.admin, users.disabled, sessions.created, sessions.last_seen
+		FROM sessions
+		JOIN users ON sessions.user_id = users.id
+		WHERE sessions.token_hash = ?1`, hashToken(token))
+	err := row.Scan(&s.User.FirstName, &s.User.LastName, &s.User.Email, &s.User.Admin, &s.User.Disabled, &created, &lastSeen)
+	if err == sql.ErrNoRows {
+		return nflpickem.Session{}, nflpickem.ErrInvalidSession
+	} else if err != nil {
+		return nflpickem.Session{}, err
+	}
+
+	s.Created = time.Unix(created, 0)
+	s.LastSeen = time.Unix(lastSeen, 0)
+
+	return s, nil
+}
+
+// TouchSession records that the session identified by the given token was used at time t.
+func (db Datastore) TouchSession(token string, t time.Time) error {
+	_, err := db.Exec("UPDATE sessions SET last_seen = ?1 WHERE token_hash = ?2", t.Unix(), hashToken(token))
+	return err
+}
+
+// DeleteSession ends the session identified by the given token.
+func (db Datastore) DeleteSession(token string) error {
+	_, err := db.Exec("DELETE FROM sessions WHERE token_hash = ?1", hashToken(token))
+	return err
+}
+
+// DeleteUserSessions ends every session belonging to the given user.
+func (db Datastore) DeleteUserSessions(username string) error {
+	_, err := db.Exec("DELETE FROM sessions WHERE user_id = (SELECT id FROM users WHERE email = ?1)", username)
+	return err
+}
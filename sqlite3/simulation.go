@@ -0,0 +1,71 @@
+package sqlite3
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// SimulateWeek estimates each user's chance of winning the given week of the NFL season
+// by simulating the games that haven't finished trials times. The same seed always
+// produces the same result.
+//
+// Picks for games that haven't started as of time t are treated as unknown, just as
+// Results leaves them out, so they earn no points in any simulated week.
+func (db Datastore) SimulateWeek(t time.Time, year int, week int, model nflpickem.OddsModel, trials int, seed int64) ([]nflpickem.WinProbability, error) {
+	picks, err := db.Picks(year, week)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range picks {
+		if !p.Game.Date.Before(t) {
+			picks[i].Selection = nflpickem.Team{}
+			picks[i].Points = 0
+		}
+	}
+
+	odds, err := db.odds(model, year, week)
+	if err != nil {
+		return nil, err
+	}
+
+	return nflpickem.Simulate(picks, odds, trials, rand.New(rand.NewSource(seed))), nil
+}
+
+// odds builds the nflpickem.OddsFunc for the given model and week of the season.
+func (db Datastore) odds(model nflpickem.OddsModel, year int, week int) (nflpickem.OddsFunc, error) {
+	switch model {
+	case nflpickem.CoinFlip:
+		return func(g nflpickem.Game) float64 { return 0.5 }, nil
+	case nflpickem.TeamRecord:
+		standings, err := db.TeamStandings(year, week)
+		if err != nil {
+			return nil, err
+		}
+		return recordOdds(standings), nil
+	default:
+		return nil, nflpickem.ErrUnknownOddsModel
+	}
+}
+
+// recordOdds weighs each team by its winning percentage in the given standings. A win
+// and a loss are added to every record so that a team without any games played isn't
+// given zero chance.
+func recordOdds(standings []nflpickem.TeamStanding) nflpickem.OddsFunc {
+	records := make(map[nflpickem.Team]nflpickem.Record)
+	for _, s := range standings {
+		records[s.Team] = s.Record
+	}
+
+	pct := func(t nflpickem.Team) float64 {
+		r := records[t]
+		return (float64(r.Wins) + float64(r.Ties)/2 + 1) / float64(r.Wins+r.Losses+r.Ties+2)
+	}
+
+	return func(g nflpickem.Game) float64 {
+		home, away := pct(g.Home), pct(g.Away)
+		return home / (home + away)
+	}
+}
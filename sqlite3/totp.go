@@ -0,0 +1,181 @@
+package sqlite3
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"strings"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// recoveryCodeCount is the number of recovery codes issued when two-factor authentication
+// is enabled.
+const recoveryCodeCount = 10
+
+// EnrollTOTP stores a new, not yet enabled, TOTP secret for the given user, replacing any
+// previous enrollment.
+func (db Datastore) EnrollTOTP(username string, secret string) error {
+	res, err := db.Exec(`INSERT OR REPLACE INTO totp(user_id, secret, enabled, last_step)
+		SELECT id, ?2, 0, 0 FROM users WHERE email = ?1`, username, secret)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return nflpickem.ErrUnknownUser
+	}
+
+	return nil
+}
+
+// TOTP returns the given user's TOTP enrollment.
+func (db Datastore) TOTP(username string) (nflpickem.TOTP, error) {
+	var o nflpickem.TOTP
+
+	row := db.QueryRow(`SELECT totp.secret, totp.enabled, totp.last_step
+		FROM totp
+		JOIN users ON totp.user_id = users.id
+		WHERE users.email = ?1`, username)
+	err := row.Scan(&o.Secret, &o.Enabled, &o.LastStep)
+	if err == sql.ErrNoRows {
+		return nflpickem.TOTP{}, nflpickem.ErrTOTPNotEnrolled
+	} else if err != nil {
+		return nflpickem.TOTP{}, err
+	}
+
+	return o, nil
+}
+
+// EnableTOTP starts requiring the second factor for the given user, replacing any previous
+// recovery codes with new ones. Only hashes of the recovery codes are stored.
+func (db Datastore) EnableTOTP(username string) ([]string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	row := tx.QueryRow("SELECT user_id FROM totp WHERE user_id = (SELECT id FROM users WHERE email = ?1)", username)
+	err = row.Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, nflpickem.ErrTOTPNotEnrolled
+	} else if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE totp SET enabled = 1 WHERE user_id = ?1", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?1", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec("INSERT INTO recovery_codes(user_id, code_hash) VALUES(?1, ?2)", userID, hashRecoveryCode(code))
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, tx.Commit()
+}
+
+// DisableTOTP removes the given user's TOTP enrollment and recovery codes.
+func (db Datastore) DisableTOTP(username string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM totp WHERE user_id = (SELECT id FROM users WHERE email = ?1)", username)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM recovery_codes WHERE user_id = (SELECT id FROM users WHERE email = ?1)", username)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep records that a code from the given time step was accepted for the given user,
+// so that it, and any earlier code, can't be used again.
+func (db Datastore) UseTOTPStep(username string, step int64) error {
+	res, err := db.Exec(`UPDATE totp SET last_step = ?2
+		WHERE user_id = (SELECT id FROM users WHERE email = ?1) AND last_step < ?2`, username, step)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// Another request accepted a code from this step first
+	if n == 0 {
+		return nflpickem.ErrInvalidTOTP
+	}
+
+	return nil
+}
+
+// RedeemRecoveryCode uses up one of the given user's recovery codes.
+func (db Datastore) RedeemRecoveryCode(username string, code string) error {
+	res, err := db.Exec(`UPDATE recovery_codes SET used = 1
+		WHERE user_id = (SELECT id FROM users WHERE email = ?1) AND code_hash = ?2 AND used = 0`, username, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return nflpickem.ErrInvalidRecoveryCode
+	}
+
+	return nil
+}
+
+// newRecoveryCode generates a random recovery code, formatted for a user to write down.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 5)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+
+	return code[:4] + "-" + code[4:], nil
+}
+
+// hashRecoveryCode returns the stored representation of a recovery code, ignoring case
+// and dashes so that codes are forgiving to type.
+func hashRecoveryCode(code string) string {
+	return hashToken(strings.ToLower(strings.Replace(code, "-", "", -1)))
+}
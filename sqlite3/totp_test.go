@@ -0,0 +1,111 @@
+package sqlite3
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// newTestDatastore creates an empty database with the current schema.
+func newTestDatastore(t *testing.T) *Datastore {
+	ddl, err := os.ReadFile(filepath.Join("..", "sql", "ddl2017.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewDatastore(filepath.Join(t.TempDir(), "nfl.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(string(ddl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	db := newTestDatastore(t)
+
+	err := db.AddUser("Alice", "Smith", "alice@example.com", "password", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.EnrollTOTP("alice@example.com", "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		step int64
+		err  error
+	}{
+		{100, nil},
+		{100, nflpickem.ErrInvalidTOTP},
+		{99, nflpickem.ErrInvalidTOTP},
+		{101, nil},
+	}
+
+	for _, s := range steps {
+		if err := db.UseTOTPStep("alice@example.com", s.step); err != s.err {
+			t.Errorf("step %d: got %v, expected %v", s.step, err, s.err)
+		}
+	}
+
+	o, err := db.TOTP("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.LastStep != 101 {
+		t.Errorf("last step is %d, expected 101", o.LastStep)
+	}
+}
+
+func TestRedeemRecoveryCode(t *testing.T) {
+	db := newTestDatastore(t)
+
+	err := db.AddUser("Alice", "Smith", "alice@example.com", "password", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.EnrollTOTP("alice@example.com", "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := db.EnableTOTP("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codes) < 3 {
+		t.Fatalf("got %d recovery codes", len(codes))
+	}
+
+	if err := db.RedeemRecoveryCode("alice@example.com", codes[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.RedeemRecoveryCode("alice@example.com", codes[0]); err != nflpickem.ErrInvalidRecoveryCode {
+		t.Errorf("reused recovery code: got %v, expected %v", err, nflpickem.ErrInvalidRecoveryCode)
+	}
+
+	// Codes are forgiving to type
+	typed := strings.ToUpper(strings.Replace(codes[1], "-", "", -1))
+	if err := db.RedeemRecoveryCode("alice@example.com", typed); err != nil {
+		t.Errorf("recovery code typed as %s: %v", typed, err)
+	}
+
+	if err := db.RedeemRecoveryCode("bob@example.com", codes[2]); err != nflpickem.ErrInvalidRecoveryCode {
+		t.Errorf("another user's recovery code: got %v, expected %v", err, nflpickem.ErrInvalidRecoveryCode)
+	}
+}
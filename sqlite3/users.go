@@ -1,6 +1,8 @@
 package sqlite3
 
 import (
+	"database/sql"
+
 	"github.com/ameske/nfl-pickem"
 
 	"golang.org/x/crypto/bcrypt"
@@ -13,22 +15,50 @@ func (db Datastore) CheckCredentials(username string, password string) (nflpicke
 	var storedPassword string
 	var user nflpickem.User
 
-	row := db.QueryRow("SELECT users.first_name, users.last_name, users.email, users.admin, users.password FROM users WHERE email = ?1", username)
-	err := row.Scan(&user.FirstName, &user.LastName, &user.Email, &user.Admin, &storedPassword)
-	if err != nil {
+	row := db.QueryRow("SELECT users.first_name, users.last_name, users.email, users.admin, users.disabled, users.pending, users.password FROM users WHERE email = ?1", username)
+	err := row.Scan(&user.FirstName, &user.LastName, &user.Email, &user.Admin, &user.Disabled, &user.Pending, &storedPassword)
+	if err == sql.ErrNoRows {
+		return unknownUser, nflpickem.ErrBadCredentials
+	} else if err != nil {
 		return unknownUser, err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(password))
-	if err != nil {
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return unknownUser, nflpickem.ErrBadCredentials
+	} else if err != nil {
 		return unknownUser, err
 	}
 
+	if user.Disabled {
+		return unknownUser, nflpickem.ErrUserDisabled
+	}
+
+	if user.Pending {
+		return unknownUser, nflpickem.ErrUserPending
+	}
+
 	return user, nil
 }
 
 // UpdatePassword updates the given user's password in the datastore, hashing it before storing it.
+// The user's current password must match oldPassword.
 func (db Datastore) UpdatePassword(username string, oldPassword string, newPassword string) error {
+	var storedPassword string
+	err := db.QueryRow("SELECT password FROM users WHERE email = ?1", username).Scan(&storedPassword)
+	if err == sql.ErrNoRows {
+		return nflpickem.ErrUnknownUser
+	} else if err != nil {
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(oldPassword))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return nflpickem.ErrIncorrectPassword
+	} else if err != nil {
+		return err
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return err
@@ -38,6 +68,7 @@ func (db Datastore) UpdatePassword(username string, oldPassword string, newPassw
 	return err
 }
 
+// AddUser adds a new user to the datastore, hashing their password before storing it.
 func (db Datastore) AddUser(first string, last string, email string, password string, admin bool) error {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -49,23 +80,84 @@ func (db Datastore) AddUser(first string, last string, email string, password st
 	return err
 }
 
-func (db Datastore) Users() ([]string, error) {
-	rows, err := db.Query("SELECT email FROM users")
+// Users returns every user in the datastore.
+func (db Datastore) Users() ([]nflpickem.User, error) {
+	rows, err := db.Query("SELECT first_name, last_name, email, admin, disabled, pending FROM users ORDER BY email")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []string
+	users := make([]nflpickem.User, 0)
 
 	for rows.Next() {
-		var tmp string
-		err := rows.Scan(&tmp)
+		var tmp nflpickem.User
+		err := rows.Scan(&tmp.FirstName, &tmp.LastName, &tmp.Email, &tmp.Admin, &tmp.Disabled, &tmp.Pending)
 		if err != nil {
 			return nil, err
 		}
 		users = append(users, tmp)
 	}
 
-	return users, nil
+	return users, rows.Err()
+}
+
+// User returns the user with the given username.
+func (db Datastore) User(username string) (nflpickem.User, error) {
+	var u nflpickem.User
+
+	row := db.QueryRow("SELECT first_name, last_name, email, admin, disabled, pending FROM users WHERE email = ?1", username)
+	err := row.Scan(&u.FirstName, &u.LastName, &u.Email, &u.Admin, &u.Disabled, &u.Pending)
+	if err == sql.ErrNoRows {
+		return nflpickem.User{}, nflpickem.ErrUnknownUser
+	} else if err != nil {
+		return nflpickem.User{}, err
+	}
+
+	return u, nil
+}
+
+// DisableUser disables or re-enables the given user. A disabled user is unable to log in.
+func (db Datastore) DisableUser(username string, disabled bool) error {
+	return db.updateUser("UPDATE users SET disabled = ?1 WHERE email = ?2", disabled, username)
+}
+
+// PromoteUser grants or revokes administrator privileges for the given user.
+func (db Datastore) PromoteUser(username string, admin bool) error {
+	return db.updateUser("UPDATE users SET admin = ?1 WHERE email = ?2", admin, username)
+}
+
+// ResetPassword overwrites the given user's password without requiring the old one, logging
+// the user out everywhere.
+func (db Datastore) ResetPassword(username string, newPassword string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	err = db.updateUser("UPDATE users SET password = ?1 WHERE email = ?2", string(hash), username)
+	if err != nil {
+		return err
+	}
+
+	return db.DeleteUserSessions(username)
+}
+
+// updateUser executes the given update, returning nflpickem.ErrUnknownUser if no user was changed.
+func (db Datastore) updateUser(sql string, value interface{}, username string) error {
+	res, err := db.Exec(sql, value, username)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return nflpickem.ErrUnknownUser
+	}
+
+	return nil
 }
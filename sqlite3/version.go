@@ -0,0 +1,49 @@
+package sqlite3
+
+import (
+	"time"
+
+	"github.com/ameske/nfl-pickem"
+)
+
+// Version returns the version of the datastore's data as of time t. The change counter is
+// kept up to date by triggers, so changes made by any program are counted.
+func (db Datastore) Version(t time.Time) (nflpickem.Version, error) {
+	var v nflpickem.Version
+	var modified, kickoff int64
+
+	err := db.QueryRow("SELECT counter, modified FROM changes WHERE id = 1").Scan(&v.Changes, &modified)
+	if err != nil {
+		return nflpickem.Version{}, err
+	}
+
+	err = db.QueryRow("SELECT COUNT(*), IFNULL(MAX(date), 0) FROM games WHERE date <= ?1", t.Unix()).Scan(&v.Started, &kickoff)
+	if err != nil {
+		return nflpickem.Version{}, err
+	}
+
+	if kickoff > modified {
+		modified = kickoff
+	}
+	v.Modified = time.Unix(modified, 0)
+
+	return v, nil
+}
+
+// WeekFinal returns whether every game of the given week has a final score. A week without
+// any games is never final.
+func (db Datastore) WeekFinal(year int, week int) (bool, error) {
+	sql := `SELECT COUNT(*), IFNULL(SUM(games.home_score < 0 OR games.away_score < 0), 0)
+	    FROM games
+	    JOIN weeks ON games.week_id = weeks.id
+	    JOIN years ON weeks.year_id = years.id
+	    WHERE years.year = ?1 AND weeks.week = ?2`
+
+	var total, unfinished int
+	err := db.QueryRow(sql, year, week).Scan(&total, &unfinished)
+	if err != nil {
+		return false, err
+	}
+
+	return total > 0 && unfinished == 0, nil
+}
@@ -0,0 +1,158 @@
+package nflpickem
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Record is a count of a team's wins, losses, and ties.
+type Record struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Ties   int `json:"ties"`
+}
+
+// Percentage returns the winning percentage of the record, counting a tie as half a win.
+func (r Record) Percentage() float64 {
+	games := r.Wins + r.Losses + r.Ties
+	if games == 0 {
+		return 0
+	}
+
+	return (float64(r.Wins) + float64(r.Ties)/2) / float64(games)
+}
+
+func (r *Record) add(pointsFor int, pointsAgainst int) {
+	switch {
+	case pointsFor > pointsAgainst:
+		r.Wins++
+	case pointsFor < pointsAgainst:
+		r.Losses++
+	default:
+		r.Ties++
+	}
+}
+
+// TeamStanding is a team's record for a season.
+//
+// Streak is the team's current run of results, such as "W3" or "L1".
+type TeamStanding struct {
+	Team             Team    `json:"team"`
+	Conference       string  `json:"conference"`
+	Division         string  `json:"division"`
+	Record                   // Overall record
+	WinPercentage    float64 `json:"percentage"`
+	PointsFor        int     `json:"pointsFor"`
+	PointsAgainst    int     `json:"pointsAgainst"`
+	DivisionRecord   Record  `json:"divisionRecord"`
+	ConferenceRecord Record  `json:"conferenceRecord"`
+	Streak           string  `json:"streak"`
+	streakResult     byte
+	streakLength     int
+}
+
+// TeamStandingsFetcher is the interface implemented by types that can calculate the
+// standings of NFL teams for a season up to a given week.
+type TeamStandingsFetcher interface {
+	TeamStandings(year int, week int) ([]TeamStanding, error)
+}
+
+// divisions maps each team's nickname to its conference and division.
+var divisions = map[string][2]string{
+	"Bills": {"AFC", "East"}, "Dolphins": {"AFC", "East"}, "Patriots": {"AFC", "East"}, "Jets": {"AFC", "East"},
+	"Ravens": {"AFC", "North"}, "Bengals": {"AFC", "North"}, "Browns": {"AFC", "North"}, "Steelers": {"AFC", "North"},
+	"Texans": {"AFC", "South"}, "Colts": {"AFC", "South"}, "Jaguars": {"AFC", "South"}, "Titans": {"AFC", "South"},
+	"Broncos": {"AFC", "West"}, "Chiefs": {"AFC", "West"}, "Raiders": {"AFC", "West"}, "Chargers": {"AFC", "West"},
+	"Cowboys": {"NFC", "East"}, "Giants": {"NFC", "East"}, "Eagles": {"NFC", "East"}, "Redskins": {"NFC", "East"},
+	"Bears": {"NFC", "North"}, "Lions": {"NFC", "North"}, "Packers": {"NFC", "North"}, "Vikings": {"NFC", "North"},
+	"Falcons": {"NFC", "South"}, "Panthers": {"NFC", "South"}, "Saints": {"NFC", "South"}, "Buccaneers": {"NFC", "South"},
+	"Cardinals": {"NFC", "West"}, "Rams": {"NFC", "West"}, "49ers": {"NFC", "West"}, "Seahawks": {"NFC", "West"},
+}
+
+// NewTeamStandings calculates the standings of every team playing in the given games.
+// Only games that have finished count towards a team's record.
+//
+// Standings are sorted by conference and division, then by winning percentage.
+func NewTeamStandings(games []Game) []TeamStanding {
+	sorted := make([]Game, len(games))
+	copy(sorted, games)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	standings := make([]TeamStanding, 0)
+	index := make(map[Team]int)
+
+	// Appending may move the standings, so pointers are only taken once both teams of a
+	// game have been added
+	idx := func(t Team) int {
+		i, ok := index[t]
+		if !ok {
+			i = len(standings)
+			index[t] = i
+			d := divisions[t.Nickname]
+			standings = append(standings, TeamStanding{Team: t, Conference: d[0], Division: d[1]})
+		}
+		return i
+	}
+
+	for _, g := range sorted {
+		hi, ai := idx(g.Home), idx(g.Away)
+		if !g.Final() {
+			continue
+		}
+
+		home, away := &standings[hi], &standings[ai]
+
+		home.record(away, g.HomeScore, g.AwayScore)
+		away.record(home, g.AwayScore, g.HomeScore)
+	}
+
+	for i := range standings {
+		s := &standings[i]
+		s.WinPercentage = s.Record.Percentage()
+		if s.streakLength > 0 {
+			s.Streak = fmt.Sprintf("%c%d", s.streakResult, s.streakLength)
+		}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Conference != b.Conference {
+			return a.Conference < b.Conference
+		}
+		if a.Division != b.Division {
+			return a.Division < b.Division
+		}
+		return a.WinPercentage > b.WinPercentage
+	})
+
+	return standings
+}
+
+// record adds the result of a finished game against opponent to the standing.
+func (s *TeamStanding) record(opponent *TeamStanding, pointsFor int, pointsAgainst int) {
+	s.Record.add(pointsFor, pointsAgainst)
+	s.PointsFor += pointsFor
+	s.PointsAgainst += pointsAgainst
+
+	if s.Conference != "" && s.Conference == opponent.Conference {
+		s.ConferenceRecord.add(pointsFor, pointsAgainst)
+		if s.Division == opponent.Division {
+			s.DivisionRecord.add(pointsFor, pointsAgainst)
+		}
+	}
+
+	result := byte('T')
+	if pointsFor > pointsAgainst {
+		result = 'W'
+	} else if pointsFor < pointsAgainst {
+		result = 'L'
+	}
+
+	if result == s.streakResult {
+		s.streakLength++
+	} else {
+		s.streakResult, s.streakLength = result, 1
+	}
+}
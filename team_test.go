@@ -0,0 +1,97 @@
+package nflpickem
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTeamStandings(t *testing.T) {
+	var (
+		bills    = Team{City: "Buffalo", Nickname: "Bills"}
+		jets     = Team{City: "New York", Nickname: "Jets"}
+		dolphins = Team{City: "Miami", Nickname: "Dolphins"}
+		patriots = Team{City: "New England", Nickname: "Patriots"}
+		bears    = Team{City: "Chicago", Nickname: "Bears"}
+	)
+
+	kickoff := time.Date(2017, time.September, 10, 13, 0, 0, 0, time.UTC)
+	game := func(day int, home Team, away Team, homeScore int, awayScore int) Game {
+		return Game{Date: kickoff.AddDate(0, 0, day), Home: home, Away: away, HomeScore: homeScore, AwayScore: awayScore}
+	}
+
+	type want struct {
+		record        Record
+		division      Record
+		conference    Record
+		pointsFor     int
+		pointsAgainst int
+		streak        string
+	}
+
+	tests := []struct {
+		name  string
+		games []Game
+		want  map[Team]want
+	}{
+		{
+			name: "newly seen away team in every game",
+			games: []Game{
+				game(0, bills, jets, 21, 12),
+				game(0, dolphins, patriots, 10, 3),
+			},
+			want: map[Team]want{
+				bills:    {Record{1, 0, 0}, Record{1, 0, 0}, Record{1, 0, 0}, 21, 12, "W1"},
+				jets:     {Record{0, 1, 0}, Record{0, 1, 0}, Record{0, 1, 0}, 12, 21, "L1"},
+				dolphins: {Record{1, 0, 0}, Record{1, 0, 0}, Record{1, 0, 0}, 10, 3, "W1"},
+				patriots: {Record{0, 1, 0}, Record{0, 1, 0}, Record{0, 1, 0}, 3, 10, "L1"},
+			},
+		},
+		{
+			name: "streaks, ties and other conferences",
+			games: []Game{
+				game(0, bills, jets, 21, 12),
+				game(7, dolphins, bills, 17, 20),
+				game(14, bills, bears, 10, 10),
+				game(21, jets, bills, 30, 7),
+				game(28, patriots, bills, 27, 24),
+			},
+			want: map[Team]want{
+				bills: {Record{2, 2, 1}, Record{2, 2, 0}, Record{2, 2, 0}, 82, 96, "L2"},
+				bears: {Record{0, 0, 1}, Record{}, Record{}, 10, 10, "T1"},
+			},
+		},
+		{
+			name: "unfinished games list the teams without a result",
+			games: []Game{
+				game(0, bills, jets, -1, -1),
+			},
+			want: map[Team]want{
+				bills: {},
+				jets:  {},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			standings := NewTeamStandings(test.games)
+
+			found := make(map[Team]TeamStanding)
+			for _, s := range standings {
+				found[s.Team] = s
+			}
+
+			for team, w := range test.want {
+				s, ok := found[team]
+				if !ok {
+					t.Fatalf("no standing for the %s", team.Nickname)
+				}
+
+				got := want{s.Record, s.DivisionRecord, s.ConferenceRecord, s.PointsFor, s.PointsAgainst, s.Streak}
+				if got != w {
+					t.Errorf("%s: got %+v, want %+v", team.Nickname, got, w)
+				}
+			}
+		})
+	}
+}
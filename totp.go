@@ -0,0 +1,135 @@
+package nflpickem
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTPPeriod is the length of time each TOTP code is valid for
+	TOTPPeriod = 30 * time.Second
+	// TOTPDigits is the number of digits in a TOTP code
+	TOTPDigits = 6
+	// TOTPSkew is the number of periods before or after the current one whose codes are
+	// also accepted, allowing for clock drift on the user's device
+	TOTPSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTP is a user's enrollment in time-based one-time password (RFC 6238) two-factor
+// authentication. The second factor is only required once the enrollment is Enabled, which
+// happens after the user proves their authenticator app produces matching codes.
+//
+// LastStep is the time step of the most recently accepted code, which can't be used again.
+type TOTP struct {
+	Secret   string `json:"-"`
+	Enabled  bool   `json:"enabled"`
+	LastStep int64  `json:"-"`
+}
+
+var (
+	ErrTOTPNotEnrolled     = newError(KindInvalid, "totp_not_enrolled", "two-factor authentication is not enrolled")
+	ErrTOTPRequired        = newError(KindBadCredentials, "totp_required", "two-factor authentication code required")
+	ErrInvalidTOTP         = newError(KindBadCredentials, "invalid_totp", "invalid two-factor authentication code")
+	ErrInvalidRecoveryCode = newError(KindBadCredentials, "invalid_recovery_code", "invalid recovery code")
+)
+
+// NewTOTPSecret generates a random, base32 encoded, TOTP secret.
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPStep returns the TOTP time step containing time t.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod/time.Second)
+}
+
+// TOTPCode computes the code for the given base32 encoded secret at the given time step.
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, as described in RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod), nil
+}
+
+// Verify checks the code against the enrollment's secret at time t, returning the time step
+// the code belongs to. Codes from within TOTPSkew steps of t are accepted, except for those
+// at or before LastStep, so that a code can't be replayed.
+func (o TOTP) Verify(code string, t time.Time) (int64, error) {
+	now := TOTPStep(t)
+
+	for step := now - TOTPSkew; step <= now+TOTPSkew; step++ {
+		if step <= o.LastStep {
+			continue
+		}
+
+		expected, err := TOTPCode(o.Secret, step)
+		if err != nil {
+			return 0, err
+		}
+
+		if hmac.Equal([]byte(code), []byte(expected)) {
+			return step, nil
+		}
+	}
+
+	return 0, ErrInvalidTOTP
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI that authenticator apps use to enroll
+// the given account, usually presented as a QR code.
+func TOTPProvisioningURI(issuer string, account string, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprintf("%d", TOTPDigits))
+	v.Set("period", fmt.Sprintf("%d", int(TOTPPeriod/time.Second)))
+
+	label := url.PathEscape(issuer + ":" + account)
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, v.Encode())
+}
+
+// TwoFactorManager is the interface implemented by types that can store two-factor
+// authentication enrollments and recovery codes.
+type TwoFactorManager interface {
+	EnrollTOTP(username string, secret string) error
+	TOTP(username string) (TOTP, error)
+	EnableTOTP(username string) (recoveryCodes []string, err error)
+	DisableTOTP(username string) error
+	UseTOTPStep(username string, step int64) error
+	RedeemRecoveryCode(username string, code string) error
+}
@@ -0,0 +1,84 @@
+package nflpickem
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// The SHA1 test vectors from RFC 6238, truncated to six digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != expected {
+			t.Errorf("code at %d is %s, expected %s", unix, code, expected)
+		}
+	}
+}
+
+func TestTOTPVerify(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2017, time.September, 10, 13, 0, 10, 0, time.UTC)
+	step := TOTPStep(now)
+
+	tests := []struct {
+		name     string
+		step     int64
+		lastStep int64
+		valid    bool
+	}{
+		{"current step", step, 0, true},
+		{"previous step", step - 1, 0, true},
+		{"next step", step + 1, 0, true},
+		{"two steps ago", step - 2, 0, false},
+		{"two steps ahead", step + 2, 0, false},
+		{"already used", step, step, false},
+		{"older than the last used", step - 1, step, false},
+		{"newer than the last used", step + 1, step, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := TOTPCode(secret, test.step)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			o := TOTP{Secret: secret, Enabled: true, LastStep: test.lastStep}
+			accepted, err := o.Verify(code, now)
+
+			if !test.valid {
+				if err != ErrInvalidTOTP {
+					t.Errorf("got step %d and error %v, expected %v", accepted, err, ErrInvalidTOTP)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if accepted != test.step {
+				t.Errorf("accepted step %d, expected %d", accepted, test.step)
+			}
+		})
+	}
+}
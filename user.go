@@ -1,17 +1,30 @@
 package nflpickem
 
+import "time"
+
 // User represents a user of the NFL Pickem' Pool
 type User struct {
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName"`
 	Email     string `json:"email"`
 	Admin     bool   `json:"admin"`
+	Disabled  bool   `json:"disabled"`
+	Pending   bool   `json:"pending"`
 }
 
 func (u User) Equal(other User) bool {
 	return u.Email == other.Email
 }
 
+var (
+	ErrUnknownUser    = newError(KindNotFound, "unknown_user", "unknown user")
+	ErrUserDisabled   = newError(KindForbidden, "user_disabled", "user is disabled")
+	ErrBadCredentials = newError(KindBadCredentials, "bad_credentials", "incorrect username or password")
+
+	ErrIncorrectPassword = newError(KindForbidden, "incorrect_password", "incorrect password")
+	ErrInvalidResetToken = newError(KindInvalid, "invalid_reset_token", "invalid or expired password reset token")
+)
+
 type UserAdder interface {
 	AddUser(first string, last string, email string, password string, admin bool) error
 }
@@ -20,6 +33,24 @@ type PasswordUpdater interface {
 	UpdatePassword(username string, oldPassword string, newPassword string) error
 }
 
+// PasswordResetter is the interface implemented by types that can reset a forgotten
+// password using a single-use token.
+type PasswordResetter interface {
+	CreatePasswordReset(username string, expires time.Time) (token string, err error)
+	RedeemPasswordReset(token string, t time.Time, newPassword string) error
+}
+
 type CredentialChecker interface {
 	CheckCredentials(username string, password string) (User, error)
 }
+
+// UserManager is the interface implemented by types that can administer the users
+// of the pool.
+type UserManager interface {
+	UserAdder
+	Users() ([]User, error)
+	User(username string) (User, error)
+	DisableUser(username string, disabled bool) error
+	PromoteUser(username string, admin bool) error
+	ResetPassword(username string, newPassword string) error
+}
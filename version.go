@@ -0,0 +1,22 @@
+package nflpickem
+
+import "time"
+
+// Version identifies the state of the pool's data at a moment in time.
+//
+// Changes counts every change made to the datastore, and Started counts the games that have
+// kicked off, since picks are revealed when a game starts without anything being written.
+// Modified is the last time either of them changed.
+type Version struct {
+	Changes  int64
+	Started  int
+	Modified time.Time
+}
+
+// Versioner is the interface implemented by types that can report the version of their
+// data as of time t, and whether every game of a week is final so that the week will no
+// longer change.
+type Versioner interface {
+	Version(t time.Time) (Version, error)
+	WeekFinal(year int, week int) (bool, error)
+}
@@ -1,8 +1,12 @@
 var currentUser = null;
 
+// The time zone kickoff times are shown in
+var timeZone = undefined;
+
 document.addEventListener("DOMContentLoaded", function() {
   currentUser = state();
   configureNavbar(currentUser != null);
+  timeZone = userTimeZone(currentUser);
 
   years = document.getElementById("yearselector");
   weeks = document.getElementById("weekselector");
@@ -60,7 +64,7 @@ function renderGamesTable(games) {
     var row = table.insertRow(table.rows.length);
 
     var cell = row.insertCell(row.cells.length);
-    cell.innerHTML = g.date;
+    cell.innerHTML = formatKickoff(g.date, timeZone);
 
     cell = row.insertCell(row.cells.length);
     cell.innerHTML = g.away.city + " " + g.away.nickname;
@@ -4,5 +4,39 @@ document.addEventListener("DOMContentLoaded", function() {
   currentUser = state();
 
   configureNavbar(currentUser != null);
+
+  let request = new XMLHttpRequest();
+  request.open("GET", "/api/oidc", true);
+  request.onload = function() {
+    if (this.status == 200 && JSON.parse(this.response).enabled) {
+      document.getElementById("oidc").style.display = "block";
+    }
+  };
+  request.send();
+
+  // A login through the identity provider sends users with two-factor authentication
+  // back here to enter their code
+  if (new URLSearchParams(window.location.search).get("otp") == "oidc") {
+    document.getElementById("message").innerHTML = "Enter your authentication code to finish logging in";
+    document.getElementById("login").style.display = "none";
+    document.getElementById("oidcotp").style.display = "block";
+  }
 });
 
+// verifyOIDCLogin sends the authentication code that finishes a login through the
+// identity provider.
+function verifyOIDCLogin() {
+  let request = new XMLHttpRequest();
+  request.open("POST", "/api/oidc/verify", true);
+  request.withCredentials = true;
+  request.setRequestHeader("X-OTP", document.getElementById("oidccode").value);
+  request.onload = function() {
+    if (this.status == 200) {
+      window.location = "/picks.html";
+    } else {
+      alert(JSON.parse(this.response).message);
+    }
+  };
+  request.send();
+}
+
@@ -142,14 +142,16 @@ function configureNavbar(isLoggedIn) {
       }
     }
   } else {
-    // Redirect to login if we tried to access picks
-    if (window.location.pathname == "/picks.html") {
+    // Redirect to login if we tried to access picks or the profile, unless we're
+    // verifying an e-mail address, which doesn't require logging in
+    let verifying = new URLSearchParams(window.location.search).has("verify");
+    if (window.location.pathname == "/picks.html" || (window.location.pathname == "/profile.html" && !verifying)) {
       window.location.assign("/login.html");
       return;
     }
-    // Remove picks and logout
+    // Remove picks, profile, and logout
     for (var li of navbar.childNodes) {
-      if (li.id == "navpicks") {
+      if (li.id == "navpicks" || li.id == "navprofile") {
         navbar.removeChild(li);
       }
       if (li.id == "navlogout") {
@@ -174,16 +176,62 @@ function state() {
   return null;
 }
 
+// userTimeZone returns the time zone the logged in user chose in their profile, or
+// undefined to use the browser's time zone.
+//
+// Parameters:
+//    user - the logged in user, or null if nobody is logged in
+function userTimeZone(user) {
+  if (user == null) {
+    return undefined;
+  }
+
+  let request = new XMLHttpRequest();
+  request.open("GET", "/api/profile", false);
+  request.withCredentials = true;
+
+  request.send();
+  if (request.status == 200) {
+    let profile = JSON.parse(request.response);
+    if (profile.timeZone != "") {
+      return profile.timeZone;
+    }
+  }
+
+  return undefined;
+}
+
+// formatKickoff formats the kickoff time of a game in the given time zone.
+//
+// Parameters:
+//    date - the game's date, as returned by the API
+//    timeZone - an IANA time zone name, or undefined to use the browser's time zone
+function formatKickoff(date, timeZone) {
+  return new Date(date).toLocaleString(undefined, {
+    timeZone: timeZone,
+    weekday: "short",
+    month: "short",
+    day: "numeric",
+    hour: "numeric",
+    minute: "2-digit",
+    timeZoneName: "short",
+  });
+}
+
 // Login to the nfl-pickem backend API. Requires two DOM elements
 // "username", and "password" that contain the username and password.
 function login() {
   let username = document.getElementById("username").value
   let password = document.getElementById("password").value
+  let otp = document.getElementById("otp").value
 
   request = new XMLHttpRequest()
   request.open("POST", "/api/login", false);
   request.withCredentials = true;
   request.setRequestHeader("Authorization", "Basic " + btoa(username+":"+password));
+  if (otp != "") {
+    request.setRequestHeader("X-OTP", otp);
+  }
 
   request.onload = function() {
     if (this.status <= 200 && this.status < 400) {
@@ -195,3 +243,31 @@ function login() {
 
   request.send()
 }
+
+var liveEvents = null;
+
+// followLiveEvents listens for changes to games and totals pushed by the server for the
+// given year and week, replacing any earlier subscription. The browser reconnects on its
+// own if the stream is interrupted.
+//
+// Parameters:
+//    year - NFL schedule year
+//    week - NFL schedule week, or null to follow every week of the year
+//    onChange - the function run with the name and data of each event
+function followLiveEvents(year, week, onChange) {
+  if (liveEvents != null) {
+    liveEvents.close();
+  }
+
+  var url = "/api/events?year=" + year;
+  if (week != null) {
+    url += "&week=" + week;
+  }
+
+  liveEvents = new EventSource(url);
+  for (let name of ["score", "status", "totals"]) {
+    liveEvents.addEventListener(name, function(e) {
+      onChange(name, JSON.parse(e.data));
+    });
+  }
+}
@@ -1,10 +1,14 @@
 // Keep track of the current user
 var currentUser = null;
 
+// The time zone kickoff times are shown in
+var timeZone = undefined;
+
 document.addEventListener("DOMContentLoaded", function() {
   currentUser = state();
 
   configureNavbar(currentUser != null);
+  timeZone = userTimeZone(currentUser);
 
   years = document.getElementById("yearselector");
   weeks = document.getElementById("weekselector");
@@ -61,9 +65,10 @@ function submitPicks() {
   let week = currentlySelectedElementValue(weeks);
 
   let request = new XMLHttpRequest();
-  request.open("POST", "/api/picks?year="+year+"&week="+week+"&username=" + currentUser.Username, true);
+  request.open("POST", "/api/picks?year="+year+"&week="+week, true);
   request.withCredentials = true;
   request.setRequestHeader("Content-Type", "application/json");
+  request.setRequestHeader("X-CSRF-Token", currentUser.CSRFToken);
 
   request.onload = function() {
     alert("Status: " + this.status + "\nResponse: " + this.response);
@@ -114,7 +119,7 @@ function isValid(picks) {
 //    week - NFL schedule week
 function loadPicks(year, week) {
   var request = new XMLHttpRequest();
-  request.open("GET", "/api/picks?year="+year+"&week="+week+"&username=" + currentUser.Username, true);
+  request.open("GET", "/api/picks?year="+year+"&week="+week, true);
   request.withCredentials = true;
 
   request.onload = function() {
@@ -166,7 +171,7 @@ function renderPick(now, pick, row) {
   let gametime = Date.parse(pick.game.date);
 
   let cell = row.insertCell(row.cells.length);
-  cell.appendChild(document.createTextNode(pick.game.date));
+  cell.appendChild(document.createTextNode(formatKickoff(pick.game.date, timeZone)));
 
   cell = row.insertCell(row.cells.length);
   cell.appendChild(document.createTextNode(pick.game.home.city + " " + pick.game.home.nickname));
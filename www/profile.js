@@ -0,0 +1,98 @@
+var currentUser = null;
+
+document.addEventListener("DOMContentLoaded", function() {
+  currentUser = state();
+  configureNavbar(currentUser != null);
+
+  let token = new URLSearchParams(window.location.search).get("verify");
+  if (token != null) {
+    verifyEmail(token);
+  }
+
+  if (currentUser != null) {
+    loadProfile();
+  } else {
+    document.getElementById("profile").style.display = "none";
+    document.getElementById("email").style.display = "none";
+  }
+});
+
+// loadProfile fills in the profile forms with the logged in user's profile.
+function loadProfile() {
+  let request = new XMLHttpRequest();
+  request.open("GET", "/api/profile", true);
+  request.withCredentials = true;
+
+  request.onload = function() {
+    let profile = JSON.parse(this.response);
+    document.getElementById("firstName").value = profile.firstName;
+    document.getElementById("lastName").value = profile.lastName;
+    document.getElementById("timeZone").value = profile.timeZone;
+    document.getElementById("notifyPicks").checked = profile.notifications.picks !== false;
+    document.getElementById("newEmail").value = profile.email;
+    if (profile.pendingEmail != "") {
+      document.getElementById("pendingEmail").innerText = "Waiting for you to verify " + profile.pendingEmail;
+    }
+  };
+
+  request.send();
+}
+
+// saveProfile stores the values of the profile form's DOM elements.
+function saveProfile() {
+  let profile = {
+    firstName: document.getElementById("firstName").value,
+    lastName: document.getElementById("lastName").value,
+    timeZone: document.getElementById("timeZone").value,
+    notifications: {
+      picks: document.getElementById("notifyPicks").checked,
+    },
+  };
+
+  let request = new XMLHttpRequest();
+  request.open("PUT", "/api/profile", true);
+  request.withCredentials = true;
+  request.setRequestHeader("X-CSRF-Token", currentUser.CSRFToken);
+
+  request.onload = function() {
+    document.getElementById("message").innerText = JSON.parse(this.response).message;
+  };
+
+  request.send(JSON.stringify(profile));
+}
+
+// changeEmail asks the backend to send a verification link to the address in
+// the "newEmail" DOM element.
+function changeEmail() {
+  let form = new FormData();
+  form.append("email", document.getElementById("newEmail").value);
+
+  let request = new XMLHttpRequest();
+  request.open("POST", "/api/profile/email", true);
+  request.withCredentials = true;
+  request.setRequestHeader("X-CSRF-Token", currentUser.CSRFToken);
+
+  request.onload = function() {
+    document.getElementById("message").innerText = JSON.parse(this.response).message;
+  };
+
+  request.send(form);
+}
+
+// verifyEmail confirms an e-mail change with the token from the verification link.
+function verifyEmail(token) {
+  let form = new FormData();
+  form.append("token", token);
+
+  let request = new XMLHttpRequest();
+  request.open("POST", "/api/profile/email/verify", true);
+
+  request.onload = function() {
+    document.getElementById("message").innerText = JSON.parse(this.response).message;
+    if (currentUser != null) {
+      loadProfile();
+    }
+  };
+
+  request.send(form);
+}
@@ -0,0 +1,30 @@
+document.addEventListener("DOMContentLoaded", function() {
+  configureNavbar(state() != null);
+
+  let code = new URLSearchParams(window.location.search).get("code");
+  if (code != null) {
+    document.getElementById("code").value = code;
+  }
+});
+
+// register creates a new account from the values of the registration form's
+// DOM elements.
+function register() {
+  let form = new FormData();
+  for (let field of ["code", "firstName", "lastName", "email", "password"]) {
+    form.append(field, document.getElementById(field).value);
+  }
+
+  let request = new XMLHttpRequest();
+  request.open("POST", "/api/register", true);
+
+  request.onload = function() {
+    let response = JSON.parse(this.response);
+    document.getElementById("message").innerText = response.message;
+    if (this.status >= 200 && this.status < 400) {
+      document.getElementById("register").style.display = "none";
+    }
+  };
+
+  request.send(form);
+}
@@ -0,0 +1,51 @@
+var resetToken = null;
+
+document.addEventListener("DOMContentLoaded", function() {
+  configureNavbar(state() != null);
+
+  resetToken = new URLSearchParams(window.location.search).get("token");
+  if (resetToken != null) {
+    document.getElementById("message").innerText = "Choose a new password";
+    document.getElementById("forgot").style.display = "none";
+    document.getElementById("reset").style.display = "";
+  }
+});
+
+// forgotPassword asks the backend to e-mail a password reset link to the user
+// in the "username" DOM element.
+function forgotPassword() {
+  let form = new FormData();
+  form.append("username", document.getElementById("username").value);
+
+  let request = new XMLHttpRequest();
+  request.open("POST", "/api/password/forgot", true);
+
+  request.onload = function() {
+    let response = JSON.parse(this.response);
+    document.getElementById("message").innerText = response.message;
+  };
+
+  request.send(form);
+}
+
+// resetPassword redeems the token from the URL, setting the password to the
+// value of the "newPassword" DOM element.
+function resetPassword() {
+  let form = new FormData();
+  form.append("token", resetToken);
+  form.append("newPassword", document.getElementById("newPassword").value);
+
+  let request = new XMLHttpRequest();
+  request.open("POST", "/api/password/reset", true);
+
+  request.onload = function() {
+    let response = JSON.parse(this.response);
+    if (this.status >= 200 && this.status < 400) {
+      window.location.assign("/login.html");
+    } else {
+      document.getElementById("message").innerText = response.message;
+    }
+  };
+
+  request.send(form);
+}
@@ -22,6 +22,13 @@ var resultsCache = [];
 //    year - NFL schedule year
 //    week - NFL schedule week
 function loadResults(year, week) {
+  followLiveEvents(year, week, function(name, data) {
+    if (name == "score") {
+      resultsCache[week] = null;
+      loadResults(year, week);
+    }
+  });
+
   if (resultsCache[week] != null) {
     renderResultsTable(resultsCache[week]);
     return;
@@ -12,6 +12,12 @@ document.addEventListener("DOMContentLoaded", function() {
 });
 
 function loadStandings(year, week) {
+  followLiveEvents(year, null, function(name, data) {
+    if (name == "totals" && data.week <= week) {
+      loadStandings(year, week);
+    }
+  });
+
   var request = new XMLHttpRequest();
   request.open("GET", "/api/totals?year=" + year + "&week=" + week + "&kind=cumulative", true);
 
@@ -0,0 +1,9 @@
+// Package www embeds the NFL Pick-Em Pool's web client, so that it is served by nfld itself.
+package www
+
+import "embed"
+
+// FS holds the pages and scripts of the web client.
+//
+//go:embed *.html *.js
+var FS embed.FS